@@ -0,0 +1,68 @@
+// Package xyliumotel provides the OpenTelemetry connector for the Xylium framework.
+// This file contains a trace-aware errgroup wrapper for concurrent work within handlers.
+package xyliumotel
+
+import (
+	"context"
+
+	"github.com/arwahdevops/xylium-core/src/xylium"
+
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sync/errgroup"
+)
+
+// defaultErrGroupTracerName is the name of the tracer used for SpanGroup child spans.
+const defaultErrGroupTracerName = "xylium.otel.errgroup"
+
+// SpanGroup is a thin wrapper around errgroup.Group whose goroutines each run in their own
+// child span of the request's active span. Create one with Connector.ErrGroup.
+type SpanGroup struct {
+	group  *errgroup.Group
+	ctx    context.Context
+	tracer trace.Tracer
+}
+
+// ErrGroup returns a SpanGroup for fanning out concurrent work from a handler, together with
+// the group's derived context. The context carries the current request span (so spans created
+// from it link correctly) and is canceled when the first scheduled function returns an error
+// or when Wait returns. Because W3C baggage lives on the Go context, baggage extracted from the
+// request or added by the handler (via baggage.ContextWithBaggage on c.GoContext()) is visible in
+// every function scheduled with Go.
+func (connector *Connector) ErrGroup(c *xylium.Context) (*SpanGroup, context.Context) {
+	group, ctx := errgroup.WithContext(c.GoContext())
+	return &SpanGroup{
+		group:  group,
+		ctx:    ctx,
+		tracer: connector.GetTracer(defaultErrGroupTracerName, trace.WithInstrumentationVersion(Version)),
+	}, ctx
+}
+
+// Go runs f in a new goroutine inside a child span named `name`.
+// The context passed to f carries that child span. A non-nil error returned by f
+// is recorded on the span and cancels the group's context, as with errgroup.Group.Go.
+func (g *SpanGroup) Go(name string, f func(ctx context.Context) error) {
+	g.group.Go(func() error {
+		ctx, span := g.tracer.Start(g.ctx, name)
+		defer span.End()
+
+		err := f(ctx)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		return err
+	})
+}
+
+// SetLimit limits the number of active goroutines in the group to at most n.
+// A negative value indicates no limit. See errgroup.Group.SetLimit.
+func (g *SpanGroup) SetLimit(n int) {
+	g.group.SetLimit(n)
+}
+
+// Wait blocks until all functions scheduled with Go have returned, then returns
+// the first non-nil error (if any) from them.
+func (g *SpanGroup) Wait() error {
+	return g.group.Wait()
+}
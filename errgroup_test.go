@@ -0,0 +1,92 @@
+package xyliumotel
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/arwahdevops/xylium-core/src/xylium"
+	"github.com/valyala/fasthttp"
+
+	"go.opentelemetry.io/otel/baggage"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestBaggageReachesSpawnedWork(t *testing.T) {
+	connector, recorder := NewTestConnector()
+	defer connector.Close()
+
+	const traceparent = "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"
+	var groupTenant, detachedTenant string
+	router := newTestRouter(connector.OtelMiddleware())
+	router.GET("/orders", func(c *xylium.Context) error {
+		group, _ := connector.ErrGroup(c)
+		group.Go("load-items", func(ctx context.Context) error {
+			groupTenant = baggage.FromContext(ctx).Member("tenant").Value()
+			return nil
+		})
+		if err := group.Wait(); err != nil {
+			return err
+		}
+
+		done := make(chan struct{})
+		detached := DetachedContext(c.GoContext())
+		go func() {
+			defer close(done)
+			ctx, span := connector.GetTracer("detached-test").Start(detached, "send-receipt")
+			defer span.End()
+			detachedTenant = baggage.FromContext(ctx).Member("tenant").Value()
+		}()
+		<-done
+		return c.String(http.StatusOK, "ok")
+	})
+	router.Handler(newTestRequest(fasthttp.MethodGet, "/orders", "traceparent", traceparent, "baggage", "tenant=acme"))
+
+	if groupTenant != "acme" {
+		t.Errorf("baggage tenant in ErrGroup work = %q, want %q", groupTenant, "acme")
+	}
+	if detachedTenant != "acme" {
+		t.Errorf("baggage tenant in DetachedContext work = %q, want %q", detachedTenant, "acme")
+	}
+
+	spans := spansByName(recorder.Ended())
+	server, ok := spans["GET /orders"]
+	if !ok {
+		t.Fatalf("no server span recorded; got %v", spanNames(recorder.Ended()))
+	}
+	for _, name := range []string{"load-items", "send-receipt"} {
+		span, ok := spans[name]
+		if !ok {
+			t.Errorf("no %q span recorded", name)
+			continue
+		}
+		if span.Parent().SpanID() != server.SpanContext().SpanID() {
+			t.Errorf("%q parent span ID = %s, want server span %s", name, span.Parent().SpanID(), server.SpanContext().SpanID())
+		}
+		if span.SpanContext().TraceID() != server.SpanContext().TraceID() {
+			t.Errorf("%q trace ID = %s, want %s", name, span.SpanContext().TraceID(), server.SpanContext().TraceID())
+		}
+	}
+	if want, _ := trace.TraceIDFromHex("0af7651916cd43dd8448eb211c80319c"); server.SpanContext().TraceID() != want {
+		t.Errorf("server span trace ID = %s, want the propagated %s", server.SpanContext().TraceID(), want)
+	}
+}
+
+// spansByName indexes recorded spans by name.
+func spansByName(spans []sdktrace.ReadOnlySpan) map[string]sdktrace.ReadOnlySpan {
+	out := make(map[string]sdktrace.ReadOnlySpan, len(spans))
+	for _, span := range spans {
+		out[span.Name()] = span
+	}
+	return out
+}
+
+// spanNames returns the names of recorded spans, for failure messages.
+func spanNames(spans []sdktrace.ReadOnlySpan) []string {
+	names := make([]string, 0, len(spans))
+	for _, span := range spans {
+		names = append(names, span.Name())
+	}
+	return names
+}
@@ -102,6 +102,8 @@ func main() {
 	// Apply the OpenTelemetry middleware provided by the connector.
 	// This will instrument all subsequent routes.
 	app.Use(otelConnector.OtelMiddleware(xyliumotel.MiddlewareConfig{
+		// Record the client's User-Agent as the standard `user_agent.original` attribute.
+		IncludeUserAgent: true,
 		// Optional: Customize middleware behavior
 		// SpanNameFormatter: func(c *xylium.Context) string {
 		// 	if pattern := c.MatchedRoutePattern(); pattern != "" { // Hypothetical if Xylium provides this
@@ -135,7 +137,6 @@ func main() {
 
 		// Add custom attributes to the span.
 		span.SetAttributes(
-			attribute.String("user.agent", c.UserAgent()),
 			attribute.String("custom.data", "example_value_for_root"),
 		)
 
@@ -177,10 +178,8 @@ func main() {
 	})
 
 	app.GET("/error-test", func(c *xylium.Context) error {
-		otelConnVal, _ := c.AppGet("otelConnector")
-		tracer := otelConnVal.(*xyliumotel.Connector).GetTracer("handler.error-test")
-
-		_, span := tracer.Start(c.GoContext(), "errorGeneratingOperation")
+		// StartSpan creates a child of the server span without looking up the connector.
+		_, span := xyliumotel.StartSpan(c, "errorGeneratingOperation")
 		defer span.End()
 
 		simulatedError := errors.New("simulated internal error occurred in /error-test handler")
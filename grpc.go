@@ -0,0 +1,191 @@
+// Package xyliumotel provides the OpenTelemetry connector for the Xylium framework.
+// This file contains gRPC server interceptors for instrumenting unary and streaming RPCs.
+package xyliumotel
+
+import (
+	"context"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0" // Consistent with otel.go
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// defaultGRPCTracerName is the name used for the tracer within the gRPC interceptors.
+const defaultGRPCTracerName = "xylium.otel.grpc"
+
+// UnaryServerInterceptor returns a gRPC unary server interceptor for OpenTelemetry instrumentation.
+// It uses the same tracer provider and propagator as the HTTP middleware, so trace IDs
+// remain consistent across HTTP and gRPC entry points of the same service.
+//
+// For each RPC the interceptor:
+//  1. Extracts trace context from incoming gRPC metadata using the Connector's Propagator.
+//  2. Starts a new server span named after the full method (e.g., "pkg.Service/Method").
+//  3. Sets `rpc.system`, `rpc.service`, and `rpc.method` attributes on the span.
+//  4. Records the resulting gRPC status code and any returned error on the span.
+//
+// Like OtelMiddleware, a connector created with Config.Disabled yields a pure pass-through, while
+// other NoOp connectors (e.g., Exporter 'none') still extract and propagate incoming trace context.
+func (connector *Connector) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	if connector.IsNoOp() {
+		if connector.propagator == nil {
+			// If the connector is fully disabled, return a pass-through interceptor.
+			return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+				return handler(ctx, req)
+			}
+		}
+		// No spans are created, but the upstream trace context still reaches the handler.
+		propagator := connector.Propagator()
+		return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			md, _ := metadata.FromIncomingContext(ctx)
+			return handler(propagator.Extract(ctx, newGRPCMetadataCarrier(md)), req)
+		}
+	}
+
+	tracer := connector.GetTracer(defaultGRPCTracerName, trace.WithInstrumentationVersion(Version))
+	propagator := connector.Propagator()
+
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		propagatedCtx := propagator.Extract(ctx, newGRPCMetadataCarrier(md))
+
+		tracedCtx, span := tracer.Start(propagatedCtx, strings.TrimPrefix(info.FullMethod, "/"),
+			trace.WithAttributes(grpcSpanAttributes(info.FullMethod)...),
+			trace.WithSpanKind(trace.SpanKindServer),
+		)
+		defer span.End()
+
+		resp, err := handler(tracedCtx, req)
+		recordGRPCStatus(span, err)
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor returns a gRPC streaming server interceptor for OpenTelemetry instrumentation.
+// It behaves like UnaryServerInterceptor, but the span covers the lifetime of the whole stream.
+// The stream passed to the handler exposes the traced context via its Context() method.
+func (connector *Connector) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	if connector.IsNoOp() {
+		if connector.propagator == nil {
+			// If the connector is fully disabled, return a pass-through interceptor.
+			return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+				return handler(srv, ss)
+			}
+		}
+		// No spans are created, but the upstream trace context still reaches the handler.
+		propagator := connector.Propagator()
+		return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			ctx := ss.Context()
+			md, _ := metadata.FromIncomingContext(ctx)
+			return handler(srv, &tracedServerStream{ServerStream: ss, ctx: propagator.Extract(ctx, newGRPCMetadataCarrier(md))})
+		}
+	}
+
+	tracer := connector.GetTracer(defaultGRPCTracerName, trace.WithInstrumentationVersion(Version))
+	propagator := connector.Propagator()
+
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx := ss.Context()
+		md, _ := metadata.FromIncomingContext(ctx)
+		propagatedCtx := propagator.Extract(ctx, newGRPCMetadataCarrier(md))
+
+		tracedCtx, span := tracer.Start(propagatedCtx, strings.TrimPrefix(info.FullMethod, "/"),
+			trace.WithAttributes(grpcSpanAttributes(info.FullMethod)...),
+			trace.WithSpanKind(trace.SpanKindServer),
+		)
+		defer span.End()
+
+		err := handler(srv, &tracedServerStream{ServerStream: ss, ctx: tracedCtx})
+		recordGRPCStatus(span, err)
+		return err
+	}
+}
+
+// grpcSpanAttributes builds the semantic RPC attributes for a gRPC full method name
+// of the form "/package.Service/Method".
+func grpcSpanAttributes(fullMethod string) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{semconv.RPCSystemGRPC}
+	name := strings.TrimPrefix(fullMethod, "/")
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		if service := name[:i]; service != "" {
+			attrs = append(attrs, semconv.RPCService(service))
+		}
+		if method := name[i+1:]; method != "" {
+			attrs = append(attrs, semconv.RPCMethod(method))
+		}
+	}
+	return attrs
+}
+
+// recordGRPCStatus sets the `rpc.grpc.status_code` attribute and the span status
+// based on the error returned by a gRPC handler.
+func recordGRPCStatus(span trace.Span, err error) {
+	st, _ := status.FromError(err) // A nil error yields an OK status.
+	span.SetAttributes(semconv.RPCGRPCStatusCodeKey.Int(int(st.Code())))
+	if err != nil {
+		span.RecordError(err)
+	}
+	// Per OTel semantic conventions, only server-side fault codes mark a server span as Error.
+	switch st.Code() {
+	case grpccodes.Unknown, grpccodes.DeadlineExceeded, grpccodes.Unimplemented,
+		grpccodes.Internal, grpccodes.Unavailable, grpccodes.DataLoss:
+		span.SetStatus(codes.Error, st.Message())
+	}
+}
+
+// tracedServerStream wraps a grpc.ServerStream so that handlers observe the
+// context enriched with the active server span.
+type tracedServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context returns the traced context for the stream.
+func (s *tracedServerStream) Context() context.Context {
+	return s.ctx
+}
+
+// grpcMetadataCarrier adapts metadata.MD to the `propagation.TextMapCarrier`
+// interface required by OpenTelemetry propagators.
+type grpcMetadataCarrier struct {
+	md metadata.MD
+}
+
+// newGRPCMetadataCarrier creates a new carrier for the given gRPC metadata.
+func newGRPCMetadataCarrier(md metadata.MD) *grpcMetadataCarrier {
+	if md == nil {
+		md = metadata.MD{}
+	}
+	return &grpcMetadataCarrier{md: md}
+}
+
+// Get retrieves the first value for a given key.
+// Implements `propagation.TextMapCarrier`.
+func (mc *grpcMetadataCarrier) Get(key string) string {
+	values := mc.md.Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// Set sets a value for a given key.
+// Implements `propagation.TextMapCarrier`.
+func (mc *grpcMetadataCarrier) Set(key string, value string) {
+	mc.md.Set(key, value)
+}
+
+// Keys returns a slice of all keys present in the metadata.
+// Implements `propagation.TextMapCarrier`.
+func (mc *grpcMetadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(mc.md))
+	for key := range mc.md {
+		keys = append(keys, key)
+	}
+	return keys
+}
@@ -0,0 +1,40 @@
+package xyliumotel
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestUnaryServerInterceptorNoOpPropagatesContext(t *testing.T) {
+	manageGlobal := false
+	connector, err := New(Config{
+		ServiceName:           "grpc-noop-test",
+		AppLogger:             discardLogger(),
+		Exporter:              ExporterNone,
+		ManageGlobalProviders: &manageGlobal,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer connector.Close()
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+		"traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01",
+	))
+	var got trace.SpanContext
+	handler := func(ctx context.Context, req any) (any, error) {
+		got = trace.SpanContextFromContext(ctx)
+		return nil, nil
+	}
+	info := &grpc.UnaryServerInfo{FullMethod: "/orders.Orders/Get"}
+	if _, err := connector.UnaryServerInterceptor()(ctx, nil, info, handler); err != nil {
+		t.Fatalf("interceptor error = %v", err)
+	}
+	if got.TraceID().String() != "0af7651916cd43dd8448eb211c80319c" || !got.IsRemote() {
+		t.Errorf("handler span context = %+v, want the remote parent from traceparent", got)
+	}
+}
@@ -0,0 +1,190 @@
+// Package xyliumotel provides the OpenTelemetry connector for the Xylium framework.
+// This file contains helpers for instrumenting individual Xylium middleware and handlers with their own spans.
+package xyliumotel
+
+import (
+	"context"
+
+	"github.com/arwahdevops/xylium-core/src/xylium"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// MiddlewareRegistrar is implemented by types that accept Xylium middleware,
+// such as *xylium.Router and *xylium.RouteGroup.
+type MiddlewareRegistrar interface {
+	Use(middlewares ...xylium.Middleware)
+}
+
+// InstrumentedUse registers `mw` on `app` (a *xylium.Router or *xylium.RouteGroup),
+// wrapped so that each invocation is recorded as a child span named "middleware.<name>".
+// This gives a per-middleware latency breakdown (e.g., auth vs. validation vs. rate-limiting)
+// without manually instrumenting each middleware.
+// Middleware registered this way should come after OtelMiddleware so its spans are parented
+// to the server span.
+func (connector *Connector) InstrumentedUse(app MiddlewareRegistrar, name string, mw xylium.Middleware) {
+	app.Use(connector.InstrumentMiddleware(name, mw))
+}
+
+// InstrumentMiddleware wraps `mw` so that each invocation is recorded as an INTERNAL span
+// named "middleware.<name>". The span covers the middleware and everything it calls downstream,
+// and the traced Go context is propagated to the rest of the chain.
+// Errors returned through the middleware are recorded on its span.
+// If the connector is NoOp, `mw` is returned unchanged.
+func (connector *Connector) InstrumentMiddleware(name string, mw xylium.Middleware) xylium.Middleware {
+	if connector.IsNoOp() {
+		return mw
+	}
+
+	tracer := connector.GetTracer(defaultMiddlewareTracerName, trace.WithInstrumentationVersion(Version))
+	spanName := "middleware." + name
+
+	return func(next xylium.HandlerFunc) xylium.HandlerFunc {
+		handler := mw(next)
+		return func(c *xylium.Context) error {
+			tracedGoCtx, span := tracer.Start(c.GoContext(), spanName,
+				trace.WithSpanKind(trace.SpanKindInternal),
+				trace.WithAttributes(attribute.String("xylium.middleware.name", name)),
+			)
+			defer span.End()
+
+			err := handler(c.WithGoContext(tracedGoCtx))
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			return err
+		}
+	}
+}
+
+// handlerSpanConfig holds the options applied by WrapHandler.
+type handlerSpanConfig struct {
+	tracerName string
+	attributes []attribute.KeyValue
+	spanKind   trace.SpanKind
+}
+
+// HandlerSpanOption customizes the span created by WrapHandler.
+type HandlerSpanOption func(*handlerSpanConfig)
+
+// WithHandlerTracerName sets the tracer name used for the handler span.
+// Defaults to "xylium.otel.middleware".
+func WithHandlerTracerName(name string) HandlerSpanOption {
+	return func(cfg *handlerSpanConfig) {
+		cfg.tracerName = name
+	}
+}
+
+// WithHandlerAttributes adds attributes to the handler span.
+func WithHandlerAttributes(attrs ...attribute.KeyValue) HandlerSpanOption {
+	return func(cfg *handlerSpanConfig) {
+		cfg.attributes = append(cfg.attributes, attrs...)
+	}
+}
+
+// WithHandlerSpanKind overrides the kind of the handler span. By default the span is
+// INTERNAL when the request already carries an active span (e.g., from OtelMiddleware),
+// and SERVER otherwise.
+func WithHandlerSpanKind(kind trace.SpanKind) HandlerSpanOption {
+	return func(cfg *handlerSpanConfig) {
+		cfg.spanKind = kind
+	}
+}
+
+// WrapHandler wraps a single handler in its own span named `name`, for selectively instrumenting
+// individual routes or overriding span names and attributes per route, independently of the
+// connector-wide middleware configuration.
+// If the request already carries an active span (e.g., from OtelMiddleware), the handler span is
+// its child. Otherwise, trace context is extracted from the request headers and the handler span
+// acts as the server span for the request.
+// If the connector is NoOp, `h` is returned unchanged.
+func (connector *Connector) WrapHandler(name string, h xylium.HandlerFunc, opts ...HandlerSpanOption) xylium.HandlerFunc {
+	if connector.IsNoOp() {
+		return h
+	}
+
+	cfg := handlerSpanConfig{tracerName: defaultMiddlewareTracerName}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	tracer := connector.GetTracer(cfg.tracerName, trace.WithInstrumentationVersion(Version))
+	propagator := connector.Propagator()
+
+	return func(c *xylium.Context) error {
+		parentGoCtx := c.GoContext()
+		spanKind := cfg.spanKind
+		if !trace.SpanContextFromContext(parentGoCtx).IsValid() {
+			// No active span: act as the entry point for this request.
+			parentGoCtx = propagator.Extract(parentGoCtx, newFastHTTPHeaderCarrier(&c.Ctx.Request.Header))
+			if spanKind == trace.SpanKindUnspecified {
+				spanKind = trace.SpanKindServer
+			}
+		} else if spanKind == trace.SpanKindUnspecified {
+			spanKind = trace.SpanKindInternal
+		}
+
+		tracedGoCtx, span := tracer.Start(parentGoCtx, name,
+			trace.WithSpanKind(spanKind),
+			trace.WithAttributes(cfg.attributes...),
+		)
+		defer span.End()
+
+		err := h(c.WithGoContext(tracedGoCtx))
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		return err
+	}
+}
+
+// StartSpan starts a child span named `name` of the request's active span and returns a Xylium
+// context carrying it (via WithGoContext), replacing the AppGet + type assertion + GetTracer
+// boilerplate in handlers:
+//
+//	ctx, span := xyliumotel.StartSpan(c, "loadUser")
+//	defer span.End()
+//	user, err := repo.Load(ctx.GoContext(), id)
+//
+// The span is created by the TracerProvider of the active span (e.g., the server span started by
+// OtelMiddleware), so it is exported through the same pipeline as the request's other spans. If
+// the request carries no local span, the global OTel TracerProvider is used.
+func StartSpan(c *xylium.Context, name string, opts ...trace.SpanStartOption) (*xylium.Context, trace.Span) {
+	goCtx := c.GoContext()
+	tracerProvider := otel.GetTracerProvider()
+	// Remote span contexts (propagated without a local span) carry no usable provider.
+	if parentSpan := trace.SpanFromContext(goCtx); parentSpan.SpanContext().IsValid() && !parentSpan.SpanContext().IsRemote() {
+		tracerProvider = parentSpan.TracerProvider()
+	}
+	tracer := tracerProvider.Tracer(defaultMiddlewareTracerName, trace.WithInstrumentationVersion(Version))
+	tracedGoCtx, span := tracer.Start(goCtx, name, opts...)
+	return c.WithGoContext(tracedGoCtx), span
+}
+
+// IsSampled reports whether the span in ctx (e.g., c.GoContext() inside a traced handler) is
+// sampled, i.e. whether its data will be exported. Handlers can use it as a cheap guard to skip
+// expensive attribute computation or custom instrumentation for traces that will not be recorded.
+// It returns false if ctx carries no span context.
+func IsSampled(ctx context.Context) bool {
+	return trace.SpanContextFromContext(ctx).IsSampled()
+}
+
+// DetachedContext returns a context carrying the same values as ctx (including its active span
+// and baggage) but not canceled when ctx is, and without its deadline. Use it for goroutines
+// started from a handler that must outlive the request while staying linked to its trace:
+//
+//	bgCtx := xyliumotel.DetachedContext(c.GoContext())
+//	go sendReceipt(bgCtx, order)
+//
+// Capture the detached context before the handler returns; the Xylium context must not be used
+// once the request has ended. The returned context never expires, so background work should
+// bound itself (e.g., with context.WithTimeout). It also keeps every value of ctx reachable until
+// the work completes. Spans started from it are children of the request span even if they end
+// after it; the request span does not wait for them, so prefer links for long-running work.
+func DetachedContext(ctx context.Context) context.Context {
+	return context.WithoutCancel(ctx)
+}
@@ -0,0 +1,78 @@
+// Package xyliumotel provides the OpenTelemetry connector for the Xylium framework.
+// This file contains helpers for correlating Xylium logs with OpenTelemetry traces.
+package xyliumotel
+
+import (
+	"context"
+
+	"github.com/arwahdevops/xylium-core/src/xylium"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Log field names used by LoggerWithTrace when binding trace context to a logger.
+const (
+	// LogFieldTraceID is the log field holding the hex-encoded trace ID of the active span.
+	LogFieldTraceID = "trace_id"
+	// LogFieldSpanID is the log field holding the hex-encoded span ID of the active span.
+	LogFieldSpanID = "span_id"
+	// LogFieldTraceFlags is the log field holding the hex-encoded W3C trace flags (e.g., "01" if sampled).
+	LogFieldTraceFlags = "trace_flags"
+	// LogFieldSampled is the access log field (see AccessLogFields) reporting whether the trace is sampled.
+	LogFieldSampled = "sampled"
+)
+
+// contextKeySpanContext is the Xylium context store key holding the trace.SpanContext of the
+// server span started by the OTel middleware.
+const contextKeySpanContext = "xylium_otel_span_context"
+
+// LoggerWithTrace returns a logger derived from `logger` with `trace_id`, `span_id`,
+// and `trace_flags` fields bound from the active span in `ctx`.
+// Unlike the automatic correlation performed by `c.Logger()`, this works with any Go context,
+// including goroutines that branch off the request context after the handler returns.
+// If `ctx` carries no valid span context, `logger` is returned unchanged.
+func (c *Connector) LoggerWithTrace(ctx context.Context, logger xylium.Logger) xylium.Logger {
+	if logger == nil {
+		return nil
+	}
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.IsValid() {
+		return logger
+	}
+	return logger.WithFields(xylium.M{
+		LogFieldTraceID:    spanContext.TraceID().String(),
+		LogFieldSpanID:     spanContext.SpanID().String(),
+		LogFieldTraceFlags: spanContext.TraceFlags().String(),
+	})
+}
+
+// AccessLogFields returns the `trace_id`, `span_id`, and `sampled` fields of the request's server
+// span, for merging into access log entries so that each logged request links to its trace:
+//
+//	fields := otelConnector.AccessLogFields(c)
+//	c.Logger().WithFields(xylium.M(fields)).Infof("%s %s %d", c.Method(), c.Path(), c.Ctx.Response.StatusCode())
+//
+// The access log middleware may be registered before or after the OTel middleware, as long as it
+// reads the fields after calling next. It returns nil if the request carries no valid span
+// context (e.g., filtered or excluded routes, or a NoOp connector).
+func (c *Connector) AccessLogFields(xc *xylium.Context) map[string]any {
+	if xc == nil {
+		return nil
+	}
+	spanContext := trace.SpanContextFromContext(xc.GoContext())
+	if !spanContext.IsValid() {
+		// Middleware registered before the OTel middleware does not see its updated Go context,
+		// but shares the context store.
+		if val, exists := xc.Get(contextKeySpanContext); exists {
+			spanContext, _ = val.(trace.SpanContext)
+		}
+	}
+	if !spanContext.IsValid() {
+		return nil
+	}
+	return map[string]any{
+		LogFieldTraceID: spanContext.TraceID().String(),
+		LogFieldSpanID:  spanContext.SpanID().String(),
+		LogFieldSampled: spanContext.IsSampled(),
+	}
+}
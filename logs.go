@@ -0,0 +1,130 @@
+// Package xyliumotel provides the OpenTelemetry connector for the Xylium framework.
+// This file contains the OpenTelemetry logs (LoggerProvider) integration.
+package xyliumotel
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploggrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdoutlog"
+	"go.opentelemetry.io/otel/log"
+	"go.opentelemetry.io/otel/log/global"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+)
+
+// LogsConfig holds configuration for the OpenTelemetry logs signal.
+// When enabled, the connector manages an *sdklog.LoggerProvider that shares the
+// service resource with the traces pipeline, so logs and traces are correlated in one backend.
+type LogsConfig struct {
+	// Enabled turns on the managed LoggerProvider. Defaults to false.
+	Enabled bool
+	// Exporter defines the type of log exporter to initialize (ExporterOTLPGRPC or ExporterStdout).
+	// If empty, the trace exporter type (Config.Exporter) is used.
+	// ExporterOTLPGRPC reuses the endpoint, TLS mode, headers, and timeout from Config.OTLP,
+	// unless OTLP is set.
+	Exporter ExporterType
+	// OTLP, if set, replaces Config.OTLP for the logs exporter, e.g. when the collector uses a
+	// different endpoint or authentication headers for logs than for traces. It is used as a
+	// whole; fields are not merged with Config.OTLP. Requires Exporter to be ExporterOTLPGRPC.
+	OTLP *OTLPConfig
+}
+
+// initInternalLoggerProvider initializes and returns an *sdklog.LoggerProvider
+// based on the connector's logs configuration (Logs.Exporter, OTLP, Resource).
+// This method is called by New() if Config.Logs.Enabled is true.
+func (c *Connector) initInternalLoggerProvider() (*sdklog.LoggerProvider, error) {
+	var exporter sdklog.Exporter
+	var err error
+
+	c.config.AppLogger.Debugf("xylium-otel: Initializing internal OTel log exporter of type '%s'.", c.config.Logs.Exporter)
+
+	switch c.config.Logs.Exporter {
+	case ExporterOTLPGRPC:
+		otlp := c.config.Logs.OTLP // Resolved by New from Config.OTLP or LogsConfig.OTLP
+		if otlp.Endpoint == "" {
+			return nil, ErrMissingOTLPEndpoint
+		}
+		opts := []otlploggrpc.Option{otlploggrpc.WithEndpoint(otlp.Endpoint)}
+		if otlp.Insecure {
+			opts = append(opts, otlploggrpc.WithInsecure())
+		}
+		if len(otlp.Headers) > 0 {
+			opts = append(opts, otlploggrpc.WithHeaders(otlp.headers()))
+		}
+		if otlp.Timeout > 0 {
+			opts = append(opts, otlploggrpc.WithTimeout(otlp.Timeout))
+		}
+		if otlp.RetryConfig != nil {
+			retry := otlp.RetryConfig.withDefaults()
+			opts = append(opts, otlploggrpc.WithRetry(otlploggrpc.RetryConfig(retry)))
+		}
+		if otlp.Compression == "gzip" {
+			opts = append(opts, otlploggrpc.WithCompressor(otlp.Compression))
+		}
+		for _, dialOption := range otlp.grpcDialOptions() {
+			opts = append(opts, otlploggrpc.WithDialOption(dialOption))
+		}
+
+		exporterCtx, cancel := context.WithTimeout(context.Background(), otlp.Timeout)
+		defer cancel()
+
+		exporter, err = otlploggrpc.New(exporterCtx, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("xylium-otel: creating OTLP gRPC log exporter to '%s': %w", otlp.Endpoint, err)
+		}
+		c.config.initLogf("xylium-otel: OTLP gRPC log exporter configured for endpoint: %s (Insecure: %t, Timeout: %v).", otlp.Endpoint, otlp.Insecure, otlp.Timeout)
+
+	case ExporterStdout:
+		var stdoutOpts []stdoutlog.Option
+		if c.config.StdoutWriter != nil {
+			stdoutOpts = append(stdoutOpts, stdoutlog.WithWriter(c.config.StdoutWriter))
+		}
+		if c.config.stdoutPrettyPrint() {
+			stdoutOpts = append(stdoutOpts, stdoutlog.WithPrettyPrint())
+		}
+		exporter, err = stdoutlog.New(stdoutOpts...)
+		if err != nil {
+			return nil, fmt.Errorf("xylium-otel: creating stdout log exporter: %w", err)
+		}
+		c.config.initLogf("xylium-otel: Stdout log exporter configured (Pretty print: %t, Custom writer: %t).", c.config.stdoutPrettyPrint(), c.config.StdoutWriter != nil)
+
+	default:
+		return nil, fmt.Errorf("xylium-otel: unsupported exporter type '%s' for internal LoggerProvider setup", c.config.Logs.Exporter)
+	}
+
+	res, err := c.buildResource()
+	if err != nil {
+		// Attempt to shutdown the exporter if resource creation fails to prevent leaks.
+		shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 5*time.Second) // Short timeout for exporter shutdown
+		defer cancelShutdown()
+		if cerr := exporter.Shutdown(shutdownCtx); cerr != nil {
+			c.config.AppLogger.Errorf("xylium-otel: Failed to shutdown log exporter after resource creation error: %v (Original resource error: %v)", cerr, err)
+		}
+		return nil, err
+	}
+
+	var batchOpts []sdklog.BatchProcessorOption
+	if c.batchTimeoutSet {
+		batchOpts = append(batchOpts, sdklog.WithExportInterval(c.batchTimeout))
+		c.config.AppLogger.Debugf("xylium-otel: Log batch export interval set to %v (Jitter: up to %v).", c.batchTimeout, c.config.BatchConfig.TimeoutJitter)
+	}
+
+	return sdklog.NewLoggerProvider(
+		sdklog.WithProcessor(sdklog.NewBatchProcessor(exporter, batchOpts...)),
+		sdklog.WithResource(res),
+	), nil
+}
+
+// GetOtelLogger returns an OpenTelemetry log.Logger for emitting structured log records.
+// If Config.Logs.Enabled is true, the logger comes from the connector's managed LoggerProvider,
+// and records are exported alongside traces. Otherwise, it returns a logger from the global
+// OTel LoggerProvider (which is a no-op unless configured elsewhere).
+// `name` is the instrumentation scope name of the component emitting the logs.
+func (c *Connector) GetOtelLogger(name string, opts ...log.LoggerOption) log.Logger {
+	if c.loggerProvider != nil {
+		return c.loggerProvider.Logger(name, opts...)
+	}
+	return global.Logger(name, opts...)
+}
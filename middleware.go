@@ -3,14 +3,31 @@
 package xyliumotel
 
 import (
+	"bytes"
+	"context"
+	"crypto/tls"
 	"fmt"
+	"io"
+	"maps"
+	"net"
 	"net/http" // For HTTP status code constants
+	"net/url"
+	"reflect"
+	"runtime"
+	"runtime/debug"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/arwahdevops/xylium-core/src/xylium"
 	"github.com/valyala/fasthttp" // For fasthttp.RequestHeader
 
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
 	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
 	semconv "go.opentelemetry.io/otel/semconv/v1.26.0" // Consistent with otel.go
 	"go.opentelemetry.io/otel/trace"
 )
@@ -32,17 +49,278 @@ type MiddlewareConfig struct {
 	// Example: `func(c *xylium.Context) string { return c.Method() + " " + c.MatchedRoutePattern() }` (if available)
 	SpanNameFormatter func(c *xylium.Context) string
 
+	// SpanKind overrides the kind of the span created for each request, e.g. trace.SpanKindInternal
+	// when the service acts as a proxy whose spans should not be counted as server entry points.
+	// It must be one of the kinds defined by the trace package; unspecified or invalid values
+	// (the latter logged as a warning) fall back to trace.SpanKindServer, the default.
+	SpanKind trace.SpanKind
+
+	// SanitizeSpanName, if true, replaces high-cardinality path segments in the span name
+	// (as produced by SpanNameFormatter) with placeholders: UUIDs become ":uuid" and purely
+	// numeric segments of at least minSanitizedNumericSegmentLength digits become ":id".
+	// This is a safety net against cardinality explosions when the matched route pattern is
+	// unavailable or a custom formatter leaks identifiers; it is not a substitute for route patterns.
+	SanitizeSpanName bool
+
 	// AdditionalAttributes allows adding a list of custom key-value attributes
 	// to every server span created by this specific middleware instance.
 	// These are added in addition to attributes from the global Connector config.
 	AdditionalAttributes []attribute.KeyValue
 
+	// ContextKeysToAttributes maps Xylium context store keys (as set with c.Set) to span attribute
+	// names, e.g. {"tenant_id": "app.tenant.id"}. The keys are read after the handler chain runs,
+	// so values set by handlers or later middleware are captured as well as those set earlier.
+	// String, bool, int, int64, and float64 values are recorded with their type; values of other
+	// types are recorded using their fmt.Stringer implementation if available and skipped otherwise.
+	// Missing keys are skipped.
+	ContextKeysToAttributes map[string]string
+
+	// RedactQueryParams lists query parameter names (case-sensitive) whose values are replaced
+	// with "REDACTED" in the recorded `url.query` attribute, e.g. []string{"token", "api_key"}.
+	// The parameter itself stays visible, so the attribute remains useful for debugging.
+	RedactQueryParams []string
+
+	// RedactAttributes maps attribute keys to functions that rewrite their string values before the
+	// server span starts, e.g. to mask `user_agent.original` or a custom attribute. It applies to
+	// the string (and string-slice) attributes the middleware sets at span start, including
+	// AdditionalAttributes; attributes added later (e.g., by handlers) are not affected.
+	RedactAttributes map[attribute.Key]func(string) string
+
+	// IncludeRequestID determines whether the request ID set by Xylium's RequestID middleware is
+	// recorded as the `xylium.request_id` attribute. Set it to false when the tracing backend
+	// correlates by trace ID alone, to save the per-span attribute cost.
+	// Defaults to true.
+	IncludeRequestID *bool // Pointer to distinguish between not set (use default true) and explicitly false.
+
 	// Filter is an optional function to conditionally skip tracing for some requests.
 	// If Filter returns true for a given xylium.Context, tracing is bypassed for that request.
 	// Useful for excluding health checks, metrics endpoints, etc.
 	Filter func(c *xylium.Context) bool
+
+	// IgnoreMethods lists HTTP methods (case-insensitive, e.g. []string{"OPTIONS"} for CORS
+	// preflights) for which no server span is created. Unlike Filter, incoming trace context is
+	// still extracted and propagated to handlers, so spans they create keep linking to the upstream trace.
+	IgnoreMethods []string
+
+	// ShouldSample is an optional per-request sampling hook. If it returns false for a request
+	// (e.g., an internal health-probe user agent), the server span is started as non-recording, so no
+	// data is emitted but the trace context still flows to handlers and downstream calls.
+	// Returning true defers to the configured Sampler. A false result takes precedence over
+	// parent-based sampling, i.e., the request is dropped even if the upstream parent was sampled.
+	// Only honored by the connector's internally managed TracerProvider.
+	ShouldSample func(c *xylium.Context) bool
+
+	// LinksExtractor is an optional function returning span links to attach to the server span
+	// at start, e.g., for fan-in/batch endpoints whose items each carry their own upstream trace context.
+	// It runs after trace context has been extracted from the request headers, so it may read the
+	// request body if needed.
+	LinksExtractor func(c *xylium.Context) []trace.Link
+
+	// SpanStartOptions is an optional general-purpose hook returning additional span start options
+	// for the request's server span (e.g., trace.WithSpanKind, extra attributes, or links computed
+	// from the request), for cases not covered by the other fields. The options are appended after
+	// the middleware's defaults, so they take precedence where the SDK applies last-wins semantics
+	// (span kind, timestamp, and attributes with the same key); links are added to any from LinksExtractor.
+	SpanStartOptions func(c *xylium.Context) []trace.SpanStartOption
+
+	// SkipIfCanceled, if true, checks the request's Go context on entry. If it is already canceled
+	// (e.g., the client gave up while the request was queued), the middleware records a minimal server
+	// span with `http.request.aborted_before_handler=true`, skips the handler chain, and returns an error
+	// wrapping the context's error. This avoids wasting work on abandoned requests under load.
+	SkipIfCanceled bool
+
+	// TraceStateKeysAsAttributes lists W3C tracestate member keys (e.g., "congo", "rojo")
+	// whose values should be extracted from the propagated parent context and recorded
+	// on the server span as "tracestate.<key>" attributes.
+	// Members not present in the incoming tracestate are silently skipped.
+	TraceStateKeysAsAttributes []string
+
+	// VersionSkewBaggageKey is the name of a propagated W3C baggage member that carries the
+	// upstream service's version (e.g., "service.version"). If set, and the incoming value differs
+	// from the connector's Config.ServiceVersion, the server span is marked with
+	// `deployment.version_skew=true`. Useful for spotting cross-version traffic during canary rollouts.
+	// Ignored if empty, if Config.ServiceVersion is empty, or if the baggage member is absent.
+	VersionSkewBaggageKey string
+
+	// AlwaysSampleAboveBytes, if greater than zero, force-samples requests whose Content-Length
+	// exceeds this many bytes, regardless of the configured Sampler. This ensures heavyweight
+	// requests (e.g., large uploads) are always captured for capacity analysis.
+	// Force-sampling is only honored by the connector's internally managed TracerProvider.
+	AlwaysSampleAboveBytes int64
+
+	// RecordAuthScheme, if true, records the authentication scheme of the request as the
+	// `http.request.auth_scheme` attribute (e.g., "bearer", "basic", "apikey"), derived only from
+	// the scheme token of the Authorization header. Credentials are never recorded.
+	// Requests without an Authorization header are recorded as "none"; headers without a
+	// recognizable scheme token are recorded as "unknown".
+	RecordAuthScheme bool
+
+	// RecordViaHeader, if true, records the request's `Via` header values (the chain of proxies or
+	// CDNs the request passed through) as the `http.request.header.via` string-slice attribute.
+	// Useful for debugging cache-related issues involving upstream proxies.
+	RecordViaHeader bool
+
+	// RecordForwardedFor, if true, records every hop of the request's `X-Forwarded-For` chain
+	// (in header order, across all header lines) as the `http.request.forwarded_for` string-slice
+	// attribute, and sets `client.address` to the hop selected by TrustedProxyCount.
+	// This distinguishes the originating client from intermediary proxy addresses.
+	RecordForwardedFor bool
+
+	// TrustedProxyCount is the number of trusted reverse proxies in front of the service. It
+	// selects the `client.address` hop when RecordForwardedFor is enabled: the address appended
+	// by the outermost trusted proxy (the TrustedProxyCount-th entry from the right of the
+	// `X-Forwarded-For` chain) is taken as the client. With the default of 0, no forwarded hop is
+	// trusted and the direct peer address is used. Entries left of the trusted hops can be forged
+	// by the client and are never used for `client.address`.
+	TrustedProxyCount int
+
+	// IncludeNetworkAttributes, if true, records the `network.protocol.name` and
+	// `network.protocol.version` attributes (e.g., "http" and "1.1", or "2" for HTTP/2 requests
+	// served through an HTTP/2 adapter) and the direct peer's `network.peer.address` and
+	// `network.peer.port`. Disabled by default since these attributes add to span size.
+	IncludeNetworkAttributes bool
+
+	// IncludeTLSAttributes, if true, records TLS details of HTTPS requests for security auditing:
+	// `tls.protocol.name`, `tls.protocol.version` (e.g., "1.3"), `tls.cipher` (the IANA cipher
+	// suite name), `tls.resumed`, and `tls.next_protocol` when ALPN negotiated one. Plain HTTP
+	// requests are skipped.
+	IncludeTLSAttributes bool
+
+	// IncludeHandlerInfo, if true, records the `code.function`, `code.filepath`, and `code.lineno`
+	// attributes of the route handler, to find which handler produced a span. Xylium does not
+	// expose the matched handler to middleware, so only handlers wrapped with HandlerInfo are
+	// tagged; their location is resolved once at registration, not per request.
+	IncludeHandlerInfo bool
+
+	// IncludeUserAgent, if true, records the request's User-Agent header as the semantic convention
+	// `user_agent.original` attribute, which tracing backends recognize for client breakdowns.
+	// Requests without a User-Agent header are not tagged.
+	IncludeUserAgent bool
+
+	// RecordRouteParamCount, if true, records the number of path parameters of the matched route
+	// (e.g., 2 for "/users/:id/orders/:orderID") as the `http.route.param_count` attribute.
+	// Parameter values are never recorded, so this is a cardinality-safe signal for
+	// distinguishing static routes from heavily parameterized ones.
+	RecordRouteParamCount bool
+
+	// RecordRouteParams, if true, records each non-empty path parameter of the matched route as an
+	// `http.route.param.<name>` attribute (e.g., `http.route.param.orderID` for "/orders/:orderID"),
+	// which makes traces filterable by entity ID. Parameter values are usually high-cardinality
+	// and may identify users; only enable this if your tracing backend indexes attributes
+	// cost-effectively and the values are not sensitive. Span names are unaffected.
+	RecordRouteParams bool
+
+	// RecordRateLimitInfo, if true, records rate-limiting information after the handler chain runs:
+	// the `X-RateLimit-Remaining` response header (as set by xylium.RateLimiter) is recorded as
+	// `http.rate_limit.remaining`, and 429 Too Many Requests responses are flagged with `rate_limited=true`.
+	RecordRateLimitInfo bool
+
+	// RecordServerTiming, if true, parses the `Server-Timing` response headers emitted by handlers
+	// after the handler chain runs and adds one `server_timing` span event per metric, carrying
+	// `server_timing.name` and, when present, `server_timing.duration_ms` and `server_timing.description`.
+	// This surfaces the sub-operation breakdown shown in browser dev tools in the trace timeline.
+	// At most maxServerTimingEvents metrics are recorded per request.
+	RecordServerTiming bool
+
+	// RecordRequestBodyBytesRead, if true, records the number of request body bytes actually
+	// consumed by the handler chain as `http.request.body.bytes_read` at span end. For streamed
+	// request bodies (fasthttp's StreamRequestBody), handlers must read the body through
+	// RequestBodyReader so consumption can be counted; comparing the attribute with the declared
+	// Content-Length reveals partial uploads and client disconnects. For fully buffered bodies
+	// the buffered body size is recorded.
+	RecordRequestBodyBytesRead bool
+
+	// RecordCacheHeaders, if true, records the response's `ETag` and `Cache-Control` headers as the
+	// `http.response.header.etag` and `http.response.header.cache_control` string-slice attributes
+	// after the handler chain runs, to help diagnose why clients or CDNs are not caching responses.
+	// Cache-Control directives form a small vocabulary, so cardinality stays low; each value is
+	// truncated to maxCacheHeaderValueLength bytes as a safeguard.
+	RecordCacheHeaders bool
+
+	// RecordPanicStack, if true, records the formatted stack trace (from debug.Stack()) of a
+	// panic raised by the handler chain as the `exception.stacktrace` attribute on the server span,
+	// where backend error UIs expect it. The stack is truncated to maxPanicStackBytes.
+	// Panics are always recorded as exception events on the span and then re-raised for
+	// Xylium's own panic recovery, regardless of this setting.
+	RecordPanicStack bool
+
+	// SetOKStatus, if true, explicitly sets the server span status to Ok for responses with a
+	// status code below 400 and no handler error. By default the status is left Unset,
+	// which OTel treats as implicitly OK but some backends and SLO tools display differently.
+	SetOKStatus bool
+
+	// TraceResponseHeader, if non-empty, is the name of a response header (e.g., "X-Trace-Id")
+	// to which the hex-encoded trace ID of the server span is written, so clients can look up
+	// their request's trace. The header is only written when the span is sampled and recording,
+	// to avoid leaking IDs of traces that will never be exported.
+	TraceResponseHeader string
+
+	// TraceparentResponseHeader, if true, additionally writes the server span's context to the
+	// W3C `traceparent` response header so clients can continue the trace. Like
+	// TraceResponseHeader, it is only written for sampled, recording spans.
+	TraceparentResponseHeader bool
+
+	// TraceWebSockets, if true, traces the lifetime of WebSocket (or other upgraded) connections.
+	// When the handler chain responds with 101 Switching Protocols and hijacks the connection,
+	// the server span records a `websocket.upgrade` event and ends as usual, and a dedicated
+	// `websocket.connection` child span is started with a `websocket.connect` event. That span
+	// ends with a `websocket.disconnect` event once the hijack handler returns and fasthttp
+	// releases the request context, i.e. when the connection is done.
+	TraceWebSockets bool
+
+	// SlowRequestThreshold, if greater than zero, marks server spans of requests that took longer
+	// than this duration with `xylium.slow_request=true` and records the measured duration in
+	// milliseconds as `http.server.duration_ms`, so slow traces can be filtered cheaply for SLO work.
+	// The duration is measured from span start to just before the span ends.
+	SlowRequestThreshold time.Duration
+
+	// OnExtractionResult, if set, is invoked for every traced request right after the incoming
+	// trace context has been extracted from the request headers. `extracted` is true if a valid
+	// remote parent span context was found (the request continues an upstream trace) and false if
+	// the server span will start a new root (headers missing or malformed). Use it to log or count
+	// new roots versus continued traces. It is not invoked for requests skipped by Filter.
+	OnExtractionResult func(c *xylium.Context, extracted bool)
+
+	// DebugPropagation, if true, logs at Debug level which propagation format (e.g., "tracecontext"
+	// or "b3") produced the request's parent context, or which trace headers were present when none
+	// produced a valid parent. Each format's headers are re-extracted in isolation to attribute the
+	// result, so this adds per-request cost and is meant for diagnosing cross-team integrations.
+	DebugPropagation bool
+
+	// WarnOnInvalidTraceparent, if true, logs a warning when a request carries a `traceparent`
+	// header that is present but cannot be parsed as W3C Trace Context. The SDK silently treats
+	// such headers as absent and starts a new root, which otherwise makes broken upstreams invisible.
+	// The raw header value is logged, truncated to maxLoggedTraceparentLength bytes.
+	WarnOnInvalidTraceparent bool
+
+	// OnSpanEnd, if set, is invoked with the fully populated server span after the response
+	// attributes and span status have been set, right before the span ends. Attributes, events, and
+	// status changes made inside the callback are still captured on the exported span, so it can
+	// add computed attributes based on the response or feed the span to a separate audit sink.
+	// The Xylium context passed in carries the traced Go context. It is not invoked if the handler
+	// chain panics.
+	OnSpanEnd func(c *xylium.Context, span trace.Span)
+
+	// ErrorWithSuccessResponse controls the span status when a handler both writes a successful
+	// (< 400) response and returns an error, a common handler bug where the client sees success
+	// while the span reports failure. Such requests are always flagged with
+	// `xylium.handler.error_with_response=true`. See ErrorWithResponsePrecedence for the options;
+	// by default the returned error takes precedence.
+	ErrorWithSuccessResponse ErrorWithResponsePrecedence
 }
 
+// ErrorWithResponsePrecedence decides which signal determines the server span status when a
+// handler writes a successful response but also returns an error.
+type ErrorWithResponsePrecedence string
+
+const (
+	// ErrorPrecedence marks the span as Error based on the returned error (the default).
+	ErrorPrecedence ErrorWithResponsePrecedence = ""
+	// StatusCodePrecedence derives the span status from the written response's status code,
+	// as the client observed it. The returned error is still recorded as an exception event.
+	StatusCodePrecedence ErrorWithResponsePrecedence = "status_code"
+)
+
 // defaultMiddlewareTracerName is the default name used for the tracer within the OTel middleware
 // if no specific TracerName is provided in MiddlewareConfig.
 const defaultMiddlewareTracerName = "xylium.otel.middleware"
@@ -60,16 +338,35 @@ const defaultMiddlewareTracerName = "xylium.otel.middleware"
 //  5. Propagates the Go `context.Context` (enriched with the active span) to subsequent handlers.
 //  6. Records errors from the handler chain on the span and sets the span status accordingly.
 //  7. Sets the HTTP response status code as a span attribute.
+//
+// If the connector was created with Config.Disabled, the returned middleware adds no per-request
+// overhead at all: it hands back the next handler unchanged. Other NoOp connectors (e.g., Exporter
+// 'none') still extract and propagate incoming trace context.
 func (connector *Connector) OtelMiddleware(mwCustomCfg ...MiddlewareConfig) xylium.Middleware {
 	if connector.IsNoOp() {
-		// If the connector is in NoOp mode (e.g., OTel disabled or failed to initialize),
-		// return a pass-through middleware that does nothing related to tracing.
+		if connector.propagator == nil {
+			// If the connector is fully disabled (Config.Disabled), no propagator was configured,
+			// so return the next handler itself: no wrapper closure, no captured state, and no
+			// per-request work or allocations compared to not applying the middleware at all.
+			if connector.config.AppLogger != nil {
+				connector.config.AppLogger.Debug("xylium-otel: OtelMiddleware requested, but connector is NoOp. Middleware will be a pass-through.")
+			}
+			return func(next xylium.HandlerFunc) xylium.HandlerFunc {
+				return next
+			}
+		}
+
+		// The connector creates no spans (e.g., Exporter is 'none'), but incoming trace context is
+		// still extracted and propagated so spans created elsewhere keep linking to the upstream trace.
 		if connector.config.AppLogger != nil {
-			connector.config.AppLogger.Debug("xylium-otel: OtelMiddleware requested, but connector is NoOp. Middleware will be a pass-through.")
+			connector.config.AppLogger.Debug("xylium-otel: OtelMiddleware requested, but connector is NoOp. Middleware will only propagate incoming trace context.")
 		}
+		propagator := connector.Propagator()
 		return func(next xylium.HandlerFunc) xylium.HandlerFunc {
 			return func(c *xylium.Context) error {
-				return next(c)
+				carrier := newFastHTTPHeaderCarrier(&c.Ctx.Request.Header)
+				propagatedCtx := propagator.Extract(c.GoContext(), carrier)
+				return next(c.WithGoContext(propagatedCtx))
 			}
 		}
 	}
@@ -84,6 +381,18 @@ func (connector *Connector) OtelMiddleware(mwCustomCfg ...MiddlewareConfig) xyli
 	if cfg.TracerName == "" {
 		cfg.TracerName = defaultMiddlewareTracerName
 	}
+	if cfg.IncludeRequestID == nil {
+		includeRequestIDDefault := true
+		cfg.IncludeRequestID = &includeRequestIDDefault
+	}
+	switch cfg.SpanKind {
+	case trace.SpanKindServer, trace.SpanKindInternal, trace.SpanKindClient, trace.SpanKindProducer, trace.SpanKindConsumer:
+	case trace.SpanKindUnspecified:
+		cfg.SpanKind = trace.SpanKindServer
+	default:
+		connector.config.AppLogger.Warnf("xylium-otel: Invalid MiddlewareConfig.SpanKind %d; using %s.", cfg.SpanKind, trace.SpanKindServer)
+		cfg.SpanKind = trace.SpanKindServer
+	}
 	if cfg.SpanNameFormatter == nil {
 		cfg.SpanNameFormatter = func(c *xylium.Context) string {
 			path := c.Path()
@@ -98,10 +407,17 @@ func (connector *Connector) OtelMiddleware(mwCustomCfg ...MiddlewareConfig) xyli
 		}
 	}
 
+	ignoredMethods := make(map[string]struct{}, len(cfg.IgnoreMethods))
+	for _, method := range cfg.IgnoreMethods {
+		ignoredMethods[strings.ToUpper(method)] = struct{}{}
+	}
+
 	// Get a tracer instance. This uses the connector's GetTracer method, which respects
 	// the ManageGlobalProviders setting (i.e., it might use a global tracer or an internal one).
-	tracer := connector.GetTracer(cfg.TracerName, trace.WithInstrumentationVersion("xylium-otel-middleware/vNext")) // TODO: Add actual version
+	tracer := connector.GetTracer(cfg.TracerName, trace.WithInstrumentationVersion(Version))
 	propagator := connector.Propagator()
+	propagatorFields := propagator.Fields()
+	semconvMode := connector.config.SemconvStabilityMode
 
 	// Return the actual Xylium middleware function.
 	return func(next xylium.HandlerFunc) xylium.HandlerFunc {
@@ -113,6 +429,11 @@ func (connector *Connector) OtelMiddleware(mwCustomCfg ...MiddlewareConfig) xyli
 				}
 				return next(c) // Bypass tracing and proceed to the next handler.
 			}
+			// Skip the span for ignored methods, but keep propagating any upstream trace context.
+			if _, ignored := ignoredMethods[strings.ToUpper(c.Method())]; ignored {
+				carrier := newFastHTTPHeaderCarrier(&c.Ctx.Request.Header)
+				return next(c.WithGoContext(propagator.Extract(c.GoContext(), carrier)))
+			}
 
 			// Step 2: Extract trace context from incoming request headers.
 			// parentGoCtx is the Go context from the Xylium context BEFORE this middleware modifies it.
@@ -120,9 +441,39 @@ func (connector *Connector) OtelMiddleware(mwCustomCfg ...MiddlewareConfig) xyli
 			carrier := newFastHTTPHeaderCarrier(&c.Ctx.Request.Header)
 			// propagatedCtx will contain the parent span context if headers were present.
 			propagatedCtx := propagator.Extract(parentGoCtx, carrier)
+			if cfg.WarnOnInvalidTraceparent {
+				warnOnInvalidTraceparent(connector.config.AppLogger, c)
+			}
+			if cfg.DebugPropagation {
+				logPropagationDebug(connector.config.AppLogger, c, propagator, propagatorFields, propagatedCtx)
+			}
+			if cfg.OnExtractionResult != nil {
+				remoteSpanContext := trace.SpanContextFromContext(propagatedCtx)
+				cfg.OnExtractionResult(c, remoteSpanContext.IsValid() && remoteSpanContext.IsRemote())
+			}
 
 			// Step 3: Determine span name and prepare attributes.
 			spanName := cfg.SpanNameFormatter(c)
+			if cfg.SanitizeSpanName {
+				spanName = sanitizeSpanName(spanName)
+			}
+
+			// Skip abandoned requests whose context was canceled before reaching the handler chain.
+			if cfg.SkipIfCanceled {
+				if ctxErr := parentGoCtx.Err(); ctxErr != nil {
+					_, abortedSpan := tracer.Start(propagatedCtx, spanName,
+						trace.WithTimestamp(connector.now()),
+						trace.WithSpanKind(cfg.SpanKind),
+						trace.WithAttributes(applySemconvStability([]attribute.KeyValue{
+							semconv.HTTPRequestMethodKey.String(c.Method()),
+							semconv.URLPathKey.String(c.Path()),
+							attribute.Bool("http.request.aborted_before_handler", true),
+						}, semconvMode)...),
+					)
+					abortedSpan.End(trace.WithTimestamp(connector.now()))
+					return fmt.Errorf("xylium-otel: request context done before handler execution: %w", ctxErr)
+				}
+			}
 			// For http.route, ideally use matched route pattern. c.Path() is a fallback.
 			httpRoute := c.Path() // TODO: Replace with c.MatchedRoutePattern() when available in Xylium core.
 
@@ -137,12 +488,95 @@ func (connector *Connector) OtelMiddleware(mwCustomCfg ...MiddlewareConfig) xyli
 			}
 			// Add URL query if present.
 			if queryBytes := c.Ctx.URI().QueryString(); len(queryBytes) > 0 {
-				attributes = append(attributes, semconv.URLQueryKey.String(string(queryBytes)))
+				query := string(queryBytes)
+				if len(cfg.RedactQueryParams) > 0 {
+					query = redactQueryParams(query, cfg.RedactQueryParams)
+				}
+				attributes = append(attributes, semconv.URLQueryKey.String(query))
 			}
-			// Add Xylium Request ID as a custom attribute if available (set by Xylium's RequestID middleware).
-			if requestIDVal, exists := c.Get(xylium.ContextKeyRequestID); exists {
-				if requestID, ok := requestIDVal.(string); ok && requestID != "" {
-					attributes = append(attributes, attribute.String("xylium.request_id", requestID))
+			// Add Xylium Request ID as a custom attribute if available (set by Xylium's RequestID middleware)
+			// and not disabled via IncludeRequestID.
+			if *cfg.IncludeRequestID {
+				if requestIDVal, exists := c.Get(xylium.ContextKeyRequestID); exists {
+					if requestID, ok := requestIDVal.(string); ok && requestID != "" {
+						attributes = append(attributes, attribute.String("xylium.request_id", requestID))
+					}
+				}
+			}
+			// Document that the upstream caller propagated this trace with the sampled flag unset.
+			// The attribute is only exported if this span is still recorded locally (e.g., force-sampled
+			// or a non-parent-based Sampler), which helps explain missing spans further down the chain.
+			if parentSpanContext := trace.SpanContextFromContext(propagatedCtx); parentSpanContext.IsValid() && !parentSpanContext.IsSampled() {
+				attributes = append(attributes, attribute.Bool("trace.propagated_not_sampled", true))
+			}
+			// Add selected tracestate members from the propagated parent context, if any.
+			if len(cfg.TraceStateKeysAsAttributes) > 0 {
+				traceState := trace.SpanContextFromContext(propagatedCtx).TraceState()
+				for _, key := range cfg.TraceStateKeysAsAttributes {
+					if value := traceState.Get(key); value != "" {
+						attributes = append(attributes, attribute.String("tracestate."+key, value))
+					}
+				}
+			}
+			// Flag cross-version traffic if the upstream version from baggage differs from ours.
+			if cfg.VersionSkewBaggageKey != "" && connector.config.ServiceVersion != "" {
+				upstreamVersion := baggage.FromContext(propagatedCtx).Member(cfg.VersionSkewBaggageKey).Value()
+				if upstreamVersion != "" && upstreamVersion != connector.config.ServiceVersion {
+					attributes = append(attributes, attribute.Bool("deployment.version_skew", true))
+				}
+			}
+			// Record the authentication scheme (never the credentials) if configured.
+			if cfg.RecordAuthScheme {
+				attributes = append(attributes, attribute.String("http.request.auth_scheme", authScheme(c.Ctx.Request.Header.Peek(fasthttp.HeaderAuthorization))))
+			}
+			// Record the proxy/CDN chain from the Via header if configured.
+			if cfg.RecordViaHeader {
+				if viaValues := c.Ctx.Request.Header.PeekAll(fasthttp.HeaderVia); len(viaValues) > 0 {
+					via := make([]string, 0, len(viaValues))
+					for _, v := range viaValues {
+						via = append(via, string(v))
+					}
+					attributes = append(attributes, attribute.StringSlice("http.request.header.via", via))
+				}
+			}
+			// Record the full X-Forwarded-For chain and the client hop selected by the trusted-proxy config.
+			if cfg.RecordForwardedFor {
+				hops := forwardedForHops(c.Ctx.Request.Header.PeekAll(fasthttp.HeaderXForwardedFor))
+				if len(hops) > 0 {
+					attributes = append(attributes, attribute.StringSlice("http.request.forwarded_for", hops))
+				}
+				attributes = append(attributes, semconv.ClientAddressKey.String(clientAddressFromHops(hops, cfg.TrustedProxyCount, c.IP())))
+			}
+			// Record the protocol version and the direct peer's address if configured.
+			if cfg.IncludeNetworkAttributes {
+				attributes = append(attributes, semconv.NetworkProtocolName("http"))
+				if version := networkProtocolVersion(c.Ctx.Request.Header.Protocol()); version != "" {
+					attributes = append(attributes, semconv.NetworkProtocolVersion(version))
+				}
+				attributes = append(attributes, networkPeerAttributes(c.Ctx.RemoteAddr())...)
+			}
+			// Record the connection's TLS details if configured (HTTPS requests only).
+			if cfg.IncludeTLSAttributes {
+				if tlsState := c.Ctx.TLSConnectionState(); tlsState != nil {
+					attributes = append(attributes, tlsAttributes(tlsState)...)
+				}
+			}
+			// Record the client's User-Agent if configured.
+			if cfg.IncludeUserAgent {
+				if userAgent := c.UserAgent(); userAgent != "" {
+					attributes = append(attributes, semconv.UserAgentOriginal(userAgent))
+				}
+			}
+			// Record the matched route's path parameter count if configured.
+			if cfg.RecordRouteParamCount {
+				attributes = append(attributes, attribute.Int("http.route.param_count", len(c.Params)))
+			}
+			// Record the matched route's path parameter values if configured (sorted for stable output).
+			if cfg.RecordRouteParams && len(c.Params) > 0 {
+				for _, name := range slices.Sorted(maps.Keys(c.Params)) {
+					if value := c.Params[name]; value != "" {
+						attributes = append(attributes, attribute.String("http.route.param."+name, value))
+					}
 				}
 			}
 			// Add any additional custom attributes from the middleware configuration.
@@ -154,15 +588,83 @@ func (connector *Connector) OtelMiddleware(mwCustomCfg ...MiddlewareConfig) xyli
 				attributes = append(attributes, semconv.DeploymentEnvironmentKey.String(connector.config.Environment))
 			}
 
-			// Define span start options.
+			// Drop requests rejected by the per-request sampling hook (context still flows).
+			if cfg.ShouldSample != nil && !cfg.ShouldSample(c) {
+				propagatedCtx = withSamplingOverride(propagatedCtx, false)
+			}
+
+			// Apply a temporary per-route sampling override, if one is active (takes precedence over ShouldSample).
+			// Overrides are keyed by the exact request path, as the matched route pattern is unavailable.
+			if ratio, overridden := connector.routeSampleOverrideFor(c.Path()); overridden {
+				propagatedCtx = withSamplingOverride(propagatedCtx, connector.randFloat64() < ratio)
+			}
+
+			// Force-sample heavyweight requests if configured (takes precedence over route overrides).
+			if cfg.AlwaysSampleAboveBytes > 0 {
+				if contentLength := int64(c.Ctx.Request.Header.ContentLength()); contentLength > cfg.AlwaysSampleAboveBytes {
+					propagatedCtx = withForceSample(propagatedCtx)
+					attributes = append(attributes,
+						semconv.HTTPRequestBodySize(int(contentLength)),
+						attribute.Bool("xylium.sampling.forced", true),
+					)
+				}
+			}
+
+			// Define span start options. Duplicate keys (e.g., an AdditionalAttributes entry overriding
+			// a built-in attribute) are coalesced first, keeping the last value as OTel would.
+			// Legacy HTTP attributes are derived last (see Config.SemconvStabilityMode), so they carry redacted values.
+			initialAttributes := applySemconvStability(redactAttributes(dedupeAttributes(attributes), cfg.RedactAttributes), semconvMode)
+			spanStartTime := connector.now()
 			spanStartOptions := []trace.SpanStartOption{
-				trace.WithAttributes(attributes...),      // Set initial attributes.
-				trace.WithSpanKind(trace.SpanKindServer), // This is a server-side span.
+				trace.WithAttributes(initialAttributes...), // Set initial attributes.
+				trace.WithSpanKind(cfg.SpanKind),           // A server-side span unless overridden.
+				trace.WithTimestamp(spanStartTime),         // Start time from Config.TimeSource.
+			}
+
+			// Attach span links (e.g., to upstream traces of batch items) if configured.
+			if cfg.LinksExtractor != nil {
+				if links := cfg.LinksExtractor(c); len(links) > 0 {
+					spanStartOptions = append(spanStartOptions, trace.WithLinks(links...))
+				}
+			}
+
+			// Append request-specific start options last so they override the defaults.
+			if cfg.SpanStartOptions != nil {
+				spanStartOptions = append(spanStartOptions, cfg.SpanStartOptions(c)...)
 			}
 
 			// Step 4: Start the new server span. `propagatedCtx` is used as the parent context.
+			// The span is counted as active until it ends, so Drain can wait for it.
+			connector.activeServerSpans.Add(1)
 			tracedGoCtx, span := tracer.Start(propagatedCtx, spanName, spanStartOptions...)
-			defer span.End() // Ensure the span is ended when this function returns.
+			defer func() { // Ensure the span is ended when this function returns.
+				spanEndTime := connector.now()
+				// Mark slow requests if configured.
+				if duration := spanEndTime.Sub(spanStartTime); cfg.SlowRequestThreshold > 0 && duration > cfg.SlowRequestThreshold {
+					span.SetAttributes(
+						attribute.Bool("xylium.slow_request", true),
+						attribute.Float64("http.server.duration_ms", float64(duration)/float64(time.Millisecond)),
+					)
+				}
+				span.End(trace.WithTimestamp(spanEndTime))
+				connector.activeServerSpans.Add(-1)
+			}()
+			// Record panics from the handler chain on the span, then re-panic so Xylium's
+			// router-level recovery still handles the response. Runs before span.End().
+			defer func() {
+				if rec := recover(); rec != nil {
+					span.RecordError(fmt.Errorf("panic: %v", rec), trace.WithStackTrace(true))
+					span.SetStatus(codes.Error, fmt.Sprintf("panic: %v", rec))
+					if cfg.RecordPanicStack {
+						stack := debug.Stack()
+						if len(stack) > maxPanicStackBytes {
+							stack = append(stack[:maxPanicStackBytes:maxPanicStackBytes], "\n... (truncated)"...)
+						}
+						span.SetAttributes(semconv.ExceptionStacktrace(string(stack)))
+					}
+					panic(rec)
+				}
+			}()
 
 			// Step 5: Inject trace_id and span_id into Xylium's context store for logging.
 			spanContext := span.SpanContext()
@@ -172,6 +674,24 @@ func (connector *Connector) OtelMiddleware(mwCustomCfg ...MiddlewareConfig) xyli
 			if spanContext.HasSpanID() {
 				c.Set(xylium.ContextKeyOtelSpanID, spanContext.SpanID().String())
 			}
+			c.Set(contextKeySpanContext, spanContext) // For AccessLogFields in middleware registered before this one
+
+			// Expose the trace to the client via response headers if configured (sampled spans only).
+			if (cfg.TraceResponseHeader != "" || cfg.TraceparentResponseHeader) && spanContext.IsSampled() && span.IsRecording() {
+				if cfg.TraceResponseHeader != "" {
+					c.Ctx.Response.Header.Set(cfg.TraceResponseHeader, spanContext.TraceID().String())
+				}
+				if cfg.TraceparentResponseHeader {
+					c.Ctx.Response.Header.Set("traceparent", fmt.Sprintf("00-%s-%s-%s", spanContext.TraceID(), spanContext.SpanID(), spanContext.TraceFlags()))
+				}
+			}
+
+			// Count consumed bytes of a streamed request body if configured (see RequestBodyReader).
+			var bodyReader *countingReader
+			if cfg.RecordRequestBodyBytesRead && c.Ctx.Request.IsBodyStream() {
+				bodyReader = &countingReader{reader: c.Ctx.RequestBodyStream()}
+				c.Set(contextKeyRequestBodyReader, bodyReader)
+			}
 
 			// Create a new Xylium Context with the OTel-enriched Go context.
 			// This ensures `c.GoContext()` in subsequent handlers returns the traced context.
@@ -182,10 +702,95 @@ func (connector *Connector) OtelMiddleware(mwCustomCfg ...MiddlewareConfig) xyli
 
 			// Step 7: After the handler chain has executed, record response information on the span.
 			statusCode := c.Ctx.Response.StatusCode()
-			span.SetAttributes(semconv.HTTPResponseStatusCodeKey.Int(statusCode))
+			span.SetAttributes(applySemconvStability([]attribute.KeyValue{semconv.HTTPResponseStatusCodeKey.Int(statusCode)}, semconvMode)...)
+
+			// Record rate-limiting information if configured.
+			if cfg.RecordRateLimitInfo {
+				if remaining, perr := strconv.Atoi(string(c.Ctx.Response.Header.Peek("X-RateLimit-Remaining"))); perr == nil {
+					span.SetAttributes(attribute.Int("http.rate_limit.remaining", remaining))
+				}
+				if statusCode == http.StatusTooManyRequests {
+					span.SetAttributes(attribute.Bool("rate_limited", true))
+				}
+			}
+
+			// Record the logical route name, if the route was named.
+			if routeName := routeNameOf(c); routeName != "" {
+				span.SetAttributes(attribute.String("xylium.route.name", routeName))
+			}
+
+			// Record the location of the route handler if configured.
+			if cfg.IncludeHandlerInfo {
+				if val, exists := c.Get(contextKeyHandlerInfo); exists {
+					if attrs, ok := val.([]attribute.KeyValue); ok {
+						span.SetAttributes(attrs...)
+					}
+				}
+			}
+
+			// Promote selected Xylium context store values to span attributes if configured.
+			for storeKey, attrName := range cfg.ContextKeysToAttributes {
+				if val, exists := c.Get(storeKey); exists {
+					if attr, ok := contextValueAttribute(attrName, val); ok {
+						span.SetAttributes(attr)
+					}
+				}
+			}
+
+			// Record how much of the request body was consumed if configured.
+			if cfg.RecordRequestBodyBytesRead {
+				if bodyReader != nil {
+					span.SetAttributes(attribute.Int64("http.request.body.bytes_read", bodyReader.n.Load()))
+				} else if !c.Ctx.Request.IsBodyStream() {
+					span.SetAttributes(attribute.Int("http.request.body.bytes_read", len(c.Ctx.Request.Body())))
+				}
+			}
+
+			// Record caching-related response headers if configured.
+			if cfg.RecordCacheHeaders {
+				if etag := responseHeaderValues(&c.Ctx.Response.Header, fasthttp.HeaderETag); len(etag) > 0 {
+					span.SetAttributes(attribute.StringSlice("http.response.header.etag", etag))
+				}
+				if cacheControl := responseHeaderValues(&c.Ctx.Response.Header, fasthttp.HeaderCacheControl); len(cacheControl) > 0 {
+					span.SetAttributes(attribute.StringSlice("http.response.header.cache_control", cacheControl))
+				}
+			}
+
+			// Record Server-Timing metrics emitted by handlers as span events if configured.
+			if cfg.RecordServerTiming {
+				for _, metric := range parseServerTiming(c.Ctx.Response.Header.PeekAll("Server-Timing")) {
+					span.AddEvent("server_timing", trace.WithAttributes(metric.attributes()...))
+				}
+			}
+
+			// Trace the upgraded connection's lifetime in a dedicated span if configured.
+			if cfg.TraceWebSockets && statusCode == http.StatusSwitchingProtocols && c.Ctx.Hijacked() {
+				span.AddEvent("websocket.upgrade")
+				_, connSpan := tracer.Start(tracedGoCtx, "websocket.connection",
+					trace.WithAttributes(semconv.HTTPRouteKey.String(httpRoute)),
+					trace.WithSpanKind(trace.SpanKindServer),
+				)
+				connSpan.AddEvent("websocket.connect")
+				// fasthttp closes io.Closer user values when it releases the request context,
+				// which for hijacked connections happens after the hijack handler returns.
+				c.Ctx.SetUserValue(webSocketSpanUserValueKey{}, &webSocketSpanCloser{span: connSpan})
+			}
+
+			// Flag handlers that wrote a successful response but still returned an error.
+			errorWithSuccessResponse := err != nil && statusCode < http.StatusBadRequest &&
+				(len(c.Ctx.Response.Body()) > 0 || c.Ctx.Response.IsBodyStream())
+			if errorWithSuccessResponse {
+				span.SetAttributes(attribute.Bool("xylium.handler.error_with_response", true))
+			}
 
 			// Set span status based on the error returned by the handler chain or the HTTP status code.
-			if err != nil {
+			if err != nil && errorWithSuccessResponse && cfg.ErrorWithSuccessResponse == StatusCodePrecedence {
+				// The client saw a successful response; keep the error as an event only.
+				span.RecordError(err, trace.WithStackTrace(true))
+				if cfg.SetOKStatus {
+					span.SetStatus(codes.Ok, "")
+				}
+			} else if err != nil {
 				// If an error was returned by a handler, record it on the span.
 				span.RecordError(err, trace.WithStackTrace(true)) // Include stack trace.
 				span.SetStatus(codes.Error, err.Error())          // Mark span status as Error.
@@ -195,7 +800,16 @@ func (connector *Connector) OtelMiddleware(mwCustomCfg ...MiddlewareConfig) xyli
 					span.SetStatus(codes.Error, fmt.Sprintf("HTTP server error: status code %d", statusCode))
 				}
 				// For HTTP status codes < 500 (e.g., 2xx success, 4xx client errors) and no Go error,
-				// the span status remains `codes.Unset` (which is implicitly OK by OTel convention if no error recorded).
+				// the span status remains `codes.Unset` (which is implicitly OK by OTel convention if no error recorded),
+				// unless SetOKStatus requests an explicit Ok for non-error responses.
+				if cfg.SetOKStatus && statusCode < http.StatusBadRequest {
+					span.SetStatus(codes.Ok, "")
+				}
+			}
+
+			// Give the user a last look at the span before the deferred span.End() runs.
+			if cfg.OnSpanEnd != nil {
+				cfg.OnSpanEnd(tracedXyliumCtx, span)
 			}
 
 			return err // Return the error (or nil) from the handler chain.
@@ -203,6 +817,80 @@ func (connector *Connector) OtelMiddleware(mwCustomCfg ...MiddlewareConfig) xyli
 	}
 }
 
+// OtelMiddlewareExcept is like OtelMiddleware, but bypasses tracing entirely for requests whose
+// path is under one of the given prefixes (e.g., []string{"/admin"} for an admin sub-router),
+// as if the middleware were not applied. Prefixes match whole path segments: "/admin" matches
+// "/admin" and "/admin/users", but not "/administrator". It is a convenience over a Filter for
+// excluding route groups from a globally mounted middleware.
+func (connector *Connector) OtelMiddlewareExcept(prefixes []string, mwCustomCfg ...MiddlewareConfig) xylium.Middleware {
+	otelMiddleware := connector.OtelMiddleware(mwCustomCfg...)
+	if len(prefixes) == 0 {
+		return otelMiddleware
+	}
+	excluded := make([]string, 0, len(prefixes))
+	for _, prefix := range prefixes {
+		switch trimmed := strings.TrimSuffix(prefix, "/"); {
+		case prefix == "":
+			continue // An empty prefix is ignored rather than excluding every path.
+		case trimmed == "":
+			excluded = append(excluded, "/") // The root prefix excludes every path.
+		default:
+			excluded = append(excluded, trimmed)
+		}
+	}
+	return func(next xylium.HandlerFunc) xylium.HandlerFunc {
+		traced := otelMiddleware(next)
+		return func(c *xylium.Context) error {
+			if hasPathPrefix(c.Path(), excluded) {
+				return next(c)
+			}
+			return traced(c)
+		}
+	}
+}
+
+// hasPathPrefix reports whether path equals one of the prefixes or lies below one of them.
+// Prefixes must not end with a slash, except for the root prefix "/".
+func hasPathPrefix(path string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if prefix == "/" {
+			return true
+		}
+		if strings.HasPrefix(path, prefix) && (len(path) == len(prefix) || path[len(prefix)] == '/') {
+			return true
+		}
+	}
+	return false
+}
+
+// maxPanicStackBytes bounds the size of the `exception.stacktrace` attribute recorded
+// for panics when MiddlewareConfig.RecordPanicStack is enabled, avoiding oversized spans.
+const maxPanicStackBytes = 16 * 1024
+
+// maxAuthSchemeLength bounds the length of a recorded authentication scheme token.
+const maxAuthSchemeLength = 32
+
+// authScheme extracts the lowercased scheme token (e.g., "bearer") from an Authorization header value.
+// Only the token before the first space is inspected, so credentials are never returned.
+// A header without a space is treated as a bare credential and reported as "unknown".
+func authScheme(header []byte) string {
+	if len(header) == 0 {
+		return "none"
+	}
+	i := bytes.IndexByte(header, ' ')
+	if i <= 0 || i > maxAuthSchemeLength {
+		return "unknown"
+	}
+	scheme := header[:i]
+	for _, b := range scheme {
+		// RFC 7230 token characters are alphanumerics plus a small set of symbols.
+		if !(b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b >= '0' && b <= '9' || bytes.IndexByte([]byte("!#$%&'*+-.^_`|~"), b) >= 0) {
+			return "unknown"
+		}
+	}
+	return strings.ToLower(string(scheme))
+}
+
 // fastHTTPHeaderCarrier adapts fasthttp.RequestHeader to the
 // `propagation.TextMapCarrier` interface required by OpenTelemetry propagators
 // for extracting trace context from HTTP headers.
@@ -236,3 +924,576 @@ func (fc *fastHTTPHeaderCarrier) Keys() []string {
 	})
 	return keys
 }
+
+// propagationFormat returns the name of the propagation format a trace header belongs to,
+// e.g. "tracecontext" for `traceparent`. Unknown headers are returned as-is.
+func propagationFormat(field string) string {
+	field = strings.ToLower(field)
+	switch {
+	case field == "traceparent" || field == "tracestate":
+		return "tracecontext"
+	case field == "b3" || strings.HasPrefix(field, "x-b3-"):
+		return "b3"
+	case field == "uber-trace-id" || strings.HasPrefix(field, "uberctx-"):
+		return "jaeger"
+	case field == "x-amzn-trace-id":
+		return "xray"
+	case strings.HasPrefix(field, "ot-tracer-"):
+		return "ottrace"
+	default:
+		return field
+	}
+}
+
+// logPropagationDebug logs which propagation format produced the parent span context of the
+// request. The headers of each format present in the request are extracted in isolation to
+// attribute a valid parent to the format(s) that yield one.
+func logPropagationDebug(logger xylium.Logger, c *xylium.Context, propagator propagation.TextMapPropagator, fields []string, propagatedCtx context.Context) {
+	if logger == nil {
+		return
+	}
+	var formats []string
+	formatFields := make(map[string][]string)
+	for _, field := range fields {
+		if len(c.Ctx.Request.Header.Peek(field)) == 0 {
+			continue
+		}
+		format := propagationFormat(field)
+		if _, seen := formatFields[format]; !seen {
+			formats = append(formats, format)
+		}
+		formatFields[format] = append(formatFields[format], field)
+	}
+
+	parentSpanContext := trace.SpanContextFromContext(propagatedCtx)
+	if !parentSpanContext.IsValid() {
+		if len(formats) == 0 {
+			logger.Debugf("xylium-otel: Middleware: No trace context headers on request %s %s; starting a new root.", c.Method(), c.Path())
+		} else {
+			logger.Debugf("xylium-otel: Middleware: Trace context headers of format(s) %v on request %s %s produced no valid parent; starting a new root.", formats, c.Method(), c.Path())
+		}
+		return
+	}
+
+	var matched []string
+	for _, format := range formats {
+		carrier := &filteredHeaderCarrier{header: &c.Ctx.Request.Header, fields: formatFields[format]}
+		if trace.SpanContextFromContext(propagator.Extract(context.Background(), carrier)).IsValid() {
+			matched = append(matched, format)
+		}
+	}
+	logger.Debugf("xylium-otel: Middleware: Parent trace context (trace_id: %s) for request %s %s extracted via %v.", parentSpanContext.TraceID(), c.Method(), c.Path(), matched)
+}
+
+// maxLoggedTraceparentLength bounds the length of a malformed `traceparent` value logged by
+// WarnOnInvalidTraceparent, so oversized or hostile headers cannot flood the logs.
+const maxLoggedTraceparentLength = 128
+
+// warnOnInvalidTraceparent logs a warning if the request's `traceparent` header is present but
+// is not a valid W3C Trace Context header. Only the W3C headers are inspected, independently of
+// the configured propagator, so a valid parent from another format does not hide the problem.
+func warnOnInvalidTraceparent(logger xylium.Logger, c *xylium.Context) {
+	raw := c.Ctx.Request.Header.Peek("traceparent")
+	if len(raw) == 0 || logger == nil {
+		return
+	}
+	carrier := &filteredHeaderCarrier{header: &c.Ctx.Request.Header, fields: []string{"traceparent", "tracestate"}}
+	if trace.SpanContextFromContext(propagation.TraceContext{}.Extract(context.Background(), carrier)).IsValid() {
+		return
+	}
+	value := string(raw)
+	if len(value) > maxLoggedTraceparentLength {
+		value = value[:maxLoggedTraceparentLength] + "...(truncated)"
+	}
+	logger.Warnf("xylium-otel: Middleware: Invalid traceparent header %q on request %s %s; it was ignored and a new trace root may be started.", value, c.Method(), c.Path())
+}
+
+// filteredHeaderCarrier is a read-only propagation.TextMapCarrier exposing only the given
+// request header fields, used to attribute an extracted context to one propagation format.
+type filteredHeaderCarrier struct {
+	header *fasthttp.RequestHeader
+	fields []string
+}
+
+// Get returns the header value if key is one of the exposed fields.
+// Implements `propagation.TextMapCarrier`.
+func (fc *filteredHeaderCarrier) Get(key string) string {
+	for _, field := range fc.fields {
+		if strings.EqualFold(field, key) {
+			return string(fc.header.Peek(key))
+		}
+	}
+	return ""
+}
+
+// Set is a no-op; the carrier is only used for extraction.
+// Implements `propagation.TextMapCarrier`.
+func (fc *filteredHeaderCarrier) Set(string, string) {}
+
+// Keys returns the exposed fields.
+// Implements `propagation.TextMapCarrier`.
+func (fc *filteredHeaderCarrier) Keys() []string {
+	return fc.fields
+}
+
+// minSanitizedNumericSegmentLength is the minimum number of digits a purely numeric path segment
+// must have to be replaced with ":id" by sanitizeSpanName. Shorter segments (e.g., "/v/2") are
+// more likely to be part of the route itself than identifiers.
+const minSanitizedNumericSegmentLength = 3
+
+// sanitizeSpanName replaces UUID segments with ":uuid" and long numeric segments with ":id"
+// in a slash-separated span name such as "GET /orders/12345".
+func sanitizeSpanName(name string) string {
+	if !strings.Contains(name, "/") {
+		return name // Nothing path-like to sanitize.
+	}
+	segments := strings.Split(name, "/")
+	changed := false
+	for i, segment := range segments {
+		switch {
+		case isUUID(segment):
+			segments[i] = ":uuid"
+			changed = true
+		case len(segment) >= minSanitizedNumericSegmentLength && isAllDigits(segment):
+			segments[i] = ":id"
+			changed = true
+		}
+	}
+	if !changed {
+		return name
+	}
+	return strings.Join(segments, "/")
+}
+
+// isUUID reports whether s is a UUID in its canonical 8-4-4-4-12 hexadecimal form.
+func isUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		switch i {
+		case 8, 13, 18, 23:
+			if s[i] != '-' {
+				return false
+			}
+		default:
+			if !isHexDigit(s[i]) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// isHexDigit reports whether b is an ASCII hexadecimal digit.
+func isHexDigit(b byte) bool {
+	return ('0' <= b && b <= '9') || ('a' <= b && b <= 'f') || ('A' <= b && b <= 'F')
+}
+
+// isAllDigits reports whether s is non-empty and consists only of ASCII digits.
+func isAllDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// networkProtocolVersion converts a request line protocol such as "HTTP/1.1" or "HTTP/2.0" into
+// the `network.protocol.version` form ("1.1", "2"). It returns "" for non-HTTP protocols.
+func networkProtocolVersion(protocol []byte) string {
+	version, found := strings.CutPrefix(string(protocol), "HTTP/")
+	if !found || version == "" {
+		return ""
+	}
+	// Major-only versions are reported without a minor component, as in the semantic conventions.
+	if major, minor, ok := strings.Cut(version, "."); ok && minor == "0" && major != "1" {
+		return major
+	}
+	return version
+}
+
+// networkPeerAttributes returns the `network.peer.address` and `network.peer.port` attributes
+// for the remote address of a connection. The port is omitted for non-IP addresses.
+func networkPeerAttributes(addr net.Addr) []attribute.KeyValue {
+	if addr == nil {
+		return nil
+	}
+	if tcpAddr, ok := addr.(*net.TCPAddr); ok {
+		return []attribute.KeyValue{semconv.NetworkPeerAddress(tcpAddr.IP.String()), semconv.NetworkPeerPort(tcpAddr.Port)}
+	}
+	host, portStr, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return []attribute.KeyValue{semconv.NetworkPeerAddress(addr.String())}
+	}
+	attrs := []attribute.KeyValue{semconv.NetworkPeerAddress(host)}
+	if port, perr := strconv.Atoi(portStr); perr == nil {
+		attrs = append(attrs, semconv.NetworkPeerPort(port))
+	}
+	return attrs
+}
+
+// tlsAttributes returns the semantic convention `tls.*` attributes describing an established
+// TLS connection.
+func tlsAttributes(state *tls.ConnectionState) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		attribute.String("tls.protocol.name", "tls"),
+		semconv.TLSCipher(tls.CipherSuiteName(state.CipherSuite)),
+		semconv.TLSResumed(state.DidResume),
+	}
+	// tls.VersionName returns e.g. "TLS 1.3"; the convention records only the version number.
+	if version, found := strings.CutPrefix(tls.VersionName(state.Version), "TLS "); found {
+		attrs = append(attrs, semconv.TLSProtocolVersion(version))
+	}
+	if state.NegotiatedProtocol != "" {
+		attrs = append(attrs, semconv.TLSNextProtocol(state.NegotiatedProtocol))
+	}
+	return attrs
+}
+
+// maxCacheHeaderValueLength bounds the length of each recorded caching header value.
+const maxCacheHeaderValueLength = 256
+
+// responseHeaderValues returns all values of a response header, each truncated to
+// maxCacheHeaderValueLength bytes, or nil if the header is not set.
+func responseHeaderValues(header *fasthttp.ResponseHeader, key string) []string {
+	rawValues := header.PeekAll(key)
+	if len(rawValues) == 0 {
+		return nil
+	}
+	values := make([]string, 0, len(rawValues))
+	for _, v := range rawValues {
+		if len(v) > maxCacheHeaderValueLength {
+			v = v[:maxCacheHeaderValueLength]
+		}
+		values = append(values, string(v))
+	}
+	return values
+}
+
+// contextValueAttribute converts a Xylium context store value into a span attribute named `name`.
+// It reports false for nil values and types that have no attribute representation.
+func contextValueAttribute(name string, val any) (attribute.KeyValue, bool) {
+	switch v := val.(type) {
+	case string:
+		return attribute.String(name, v), true
+	case bool:
+		return attribute.Bool(name, v), true
+	case int:
+		return attribute.Int(name, v), true
+	case int64:
+		return attribute.Int64(name, v), true
+	case float64:
+		return attribute.Float64(name, v), true
+	case fmt.Stringer:
+		return attribute.Stringer(name, v), true
+	default:
+		return attribute.KeyValue{}, false
+	}
+}
+
+// contextKeyRouteName is the Xylium context store key holding the route name set by RouteName.
+const contextKeyRouteName = "xylium_otel_route_name"
+
+// RouteName returns a route-level middleware that names the route for tracing. The OTel
+// middleware records the name as the `xylium.route.name` attribute on the server span, so
+// operations can be grouped logically even when routes share a pattern:
+//
+//	app.GET("/users/:id", getUser, xyliumotel.RouteName("users.get"))
+//
+// Xylium Core does not name routes itself yet; if a future version exposes a RouteName method on
+// the context, its value is used when this middleware was not applied.
+func RouteName(name string) xylium.Middleware {
+	return func(next xylium.HandlerFunc) xylium.HandlerFunc {
+		return func(c *xylium.Context) error {
+			c.Set(contextKeyRouteName, name)
+			return next(c)
+		}
+	}
+}
+
+// routeNameOf returns the route name set by RouteName, or by Xylium itself if its context exposes
+// one, or "" for unnamed routes. It is read after the handler chain has run.
+func routeNameOf(c *xylium.Context) string {
+	if val, exists := c.Get(contextKeyRouteName); exists {
+		if name, ok := val.(string); ok && name != "" {
+			return name
+		}
+	}
+	if named, ok := any(c).(interface{ RouteName() string }); ok {
+		return named.RouteName()
+	}
+	return ""
+}
+
+// contextKeyHandlerInfo is the Xylium context store key holding the code attributes of the
+// handler wrapped by HandlerInfo.
+const contextKeyHandlerInfo = "xylium_otel_handler_info"
+
+// HandlerInfo wraps a route handler so that the OTel middleware can record its function name and
+// source location when MiddlewareConfig.IncludeHandlerInfo is true:
+//
+//	app.GET("/users/:id", xyliumotel.HandlerInfo(getUser))
+//
+// The location is resolved through the runtime once, when HandlerInfo is called; each request
+// only stores the precomputed attributes in the context. Anonymous handlers are reported under
+// their compiler-generated names (e.g., "main.main.func1").
+func HandlerInfo(h xylium.HandlerFunc) xylium.HandlerFunc {
+	attrs := handlerCodeAttributes(h)
+	if len(attrs) == 0 {
+		return h
+	}
+	return func(c *xylium.Context) error {
+		c.Set(contextKeyHandlerInfo, attrs)
+		return h(c)
+	}
+}
+
+// handlerCodeAttributes returns the `code.function`, `code.filepath`, and `code.lineno`
+// attributes of h, or nil if the runtime cannot resolve its function.
+func handlerCodeAttributes(h xylium.HandlerFunc) []attribute.KeyValue {
+	if h == nil {
+		return nil
+	}
+	fn := runtime.FuncForPC(reflect.ValueOf(h).Pointer())
+	if fn == nil {
+		return nil
+	}
+	file, line := fn.FileLine(fn.Entry())
+	return []attribute.KeyValue{
+		semconv.CodeFunction(fn.Name()),
+		semconv.CodeFilepath(file),
+		semconv.CodeLineNumber(line),
+	}
+}
+
+// contextKeyRequestBodyReader is the Xylium context store key holding the counting
+// request body reader installed by RecordRequestBodyBytesRead.
+const contextKeyRequestBodyReader = "xylium_otel_request_body_reader"
+
+// RequestBodyReader returns the reader handlers should use to consume a streamed request body.
+// If the OTel middleware is counting consumed bytes (MiddlewareConfig.RecordRequestBodyBytesRead),
+// the counting reader is returned; otherwise fasthttp's request body stream is returned as-is.
+// It returns nil if the request body is not streamed (use c.Body() instead).
+func RequestBodyReader(c *xylium.Context) io.Reader {
+	if val, exists := c.Get(contextKeyRequestBodyReader); exists {
+		if reader, ok := val.(*countingReader); ok {
+			return reader
+		}
+	}
+	return c.Ctx.RequestBodyStream()
+}
+
+// countingReader wraps an io.Reader and counts the bytes read through it.
+// The count is safe to load concurrently with reads.
+type countingReader struct {
+	reader io.Reader
+	n      atomic.Int64
+}
+
+// Read reads from the wrapped reader and adds the number of bytes read to the count.
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.n.Add(int64(n))
+	return n, err
+}
+
+// redactedValue replaces the values of redacted query parameters.
+const redactedValue = "REDACTED"
+
+// redactQueryParams replaces the values of the named parameters in a raw query string with
+// redactedValue, preserving the order and encoding of all other parameters.
+func redactQueryParams(query string, names []string) string {
+	pairs := strings.Split(query, "&")
+	changed := false
+	for i, pair := range pairs {
+		rawKey, _, _ := strings.Cut(pair, "=")
+		key := rawKey
+		if unescaped, err := url.QueryUnescape(rawKey); err == nil {
+			key = unescaped
+		}
+		if slices.Contains(names, key) {
+			pairs[i] = rawKey + "=" + redactedValue
+			changed = true
+		}
+	}
+	if !changed {
+		return query
+	}
+	return strings.Join(pairs, "&")
+}
+
+// redactAttributes rewrites the string and string-slice values of attributes whose keys have a
+// redaction function in `redactors`. The slice is modified in place and returned.
+func redactAttributes(attrs []attribute.KeyValue, redactors map[attribute.Key]func(string) string) []attribute.KeyValue {
+	if len(redactors) == 0 {
+		return attrs
+	}
+	for i, kv := range attrs {
+		redact, ok := redactors[kv.Key]
+		if !ok {
+			continue
+		}
+		switch kv.Value.Type() {
+		case attribute.STRING:
+			attrs[i] = kv.Key.String(redact(kv.Value.AsString()))
+		case attribute.STRINGSLICE:
+			values := kv.Value.AsStringSlice()
+			for j, v := range values {
+				values[j] = redact(v)
+			}
+			attrs[i] = kv.Key.StringSlice(values)
+		}
+	}
+	return attrs
+}
+
+// dedupeAttributes removes attributes with duplicate keys in place, keeping the last value set
+// for each key at the position of its first occurrence (matching OTel's last-wins semantics).
+// The returned slice shares the backing array of attrs. A linear scan is used since server span
+// attribute lists are short, which avoids allocating a map per request.
+func dedupeAttributes(attrs []attribute.KeyValue) []attribute.KeyValue {
+	n := 0
+outer:
+	for _, kv := range attrs {
+		for i := 0; i < n; i++ {
+			if attrs[i].Key == kv.Key {
+				attrs[i].Value = kv.Value
+				continue outer
+			}
+		}
+		attrs[n] = kv
+		n++
+	}
+	return attrs[:n]
+}
+
+// webSocketSpanUserValueKey is the fasthttp user value key under which the
+// `websocket.connection` span of an upgraded request is stored.
+type webSocketSpanUserValueKey struct{}
+
+// webSocketSpanCloser ends a `websocket.connection` span when fasthttp releases the
+// request context of a hijacked connection. Implements io.Closer.
+type webSocketSpanCloser struct {
+	span trace.Span
+}
+
+// Close records the disconnect event and ends the connection span.
+func (w *webSocketSpanCloser) Close() error {
+	w.span.AddEvent("websocket.disconnect")
+	w.span.End()
+	return nil
+}
+
+// maxServerTimingEvents caps the number of Server-Timing metrics recorded as span events per request.
+const maxServerTimingEvents = 32
+
+// serverTimingMetric is a single metric parsed from a `Server-Timing` header,
+// e.g. `db;dur=53.2;desc="Primary DB"`.
+type serverTimingMetric struct {
+	name        string
+	duration    float64 // In milliseconds, as defined by the Server-Timing specification.
+	hasDuration bool
+	description string
+}
+
+// attributes returns the span event attributes describing the metric.
+func (m serverTimingMetric) attributes() []attribute.KeyValue {
+	attrs := []attribute.KeyValue{attribute.String("server_timing.name", m.name)}
+	if m.hasDuration {
+		attrs = append(attrs, attribute.Float64("server_timing.duration_ms", m.duration))
+	}
+	if m.description != "" {
+		attrs = append(attrs, attribute.String("server_timing.description", m.description))
+	}
+	return attrs
+}
+
+// parseServerTiming parses the values of all `Server-Timing` header lines into metrics.
+// Malformed metrics and parameters are skipped rather than failing the whole header.
+func parseServerTiming(headerValues [][]byte) []serverTimingMetric {
+	var metrics []serverTimingMetric
+	for _, value := range headerValues {
+		for _, entry := range splitServerTimingList(string(value), ',') {
+			params := splitServerTimingList(entry, ';')
+			metric := serverTimingMetric{name: strings.TrimSpace(params[0])}
+			if metric.name == "" {
+				continue
+			}
+			for _, param := range params[1:] {
+				key, val, found := strings.Cut(param, "=")
+				if !found {
+					continue
+				}
+				val = strings.Trim(strings.TrimSpace(val), `"`)
+				switch strings.ToLower(strings.TrimSpace(key)) {
+				case "dur":
+					if dur, err := strconv.ParseFloat(val, 64); err == nil && !metric.hasDuration {
+						metric.duration, metric.hasDuration = dur, true
+					}
+				case "desc":
+					if metric.description == "" {
+						metric.description = val
+					}
+				}
+			}
+			metrics = append(metrics, metric)
+			if len(metrics) == maxServerTimingEvents {
+				return metrics
+			}
+		}
+	}
+	return metrics
+}
+
+// splitServerTimingList splits s on sep, ignoring separators inside double-quoted strings
+// (e.g., a `desc` containing commas or semicolons).
+func splitServerTimingList(s string, sep byte) []string {
+	var parts []string
+	inQuotes, start := false, 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			inQuotes = !inQuotes
+		case sep:
+			if !inQuotes {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// forwardedForHops flattens the values of all `X-Forwarded-For` header lines into a single
+// ordered list of hops, trimming whitespace and dropping empty entries.
+func forwardedForHops(headerValues [][]byte) []string {
+	var hops []string
+	for _, value := range headerValues {
+		for _, hop := range strings.Split(string(value), ",") {
+			if hop = strings.TrimSpace(hop); hop != "" {
+				hops = append(hops, hop)
+			}
+		}
+	}
+	return hops
+}
+
+// clientAddressFromHops selects the client address from an `X-Forwarded-For` chain given the
+// number of trusted proxies in front of the service. Each trusted proxy appends the address it
+// received the request from, so the client is the trustedProxies-th hop from the right.
+// If no proxy is trusted, peerAddress (the direct connection's address) is returned; if the chain
+// is shorter than the trusted count, the leftmost hop is returned.
+func clientAddressFromHops(hops []string, trustedProxies int, peerAddress string) string {
+	if trustedProxies <= 0 || len(hops) == 0 {
+		return peerAddress
+	}
+	if trustedProxies > len(hops) {
+		return hops[0]
+	}
+	return hops[len(hops)-trustedProxies]
+}
@@ -0,0 +1,391 @@
+package xyliumotel
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/arwahdevops/xylium-core/src/xylium"
+	"github.com/valyala/fasthttp"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// newTestRouter returns a router using the given global middleware and a discarding logger.
+func newTestRouter(middleware ...xylium.Middleware) *xylium.Router {
+	serverConfig := xylium.DefaultServerConfig()
+	serverConfig.Logger = discardLogger()
+	router := xylium.NewWithConfig(serverConfig)
+	router.Use(middleware...)
+	return router
+}
+
+// newTestRequest returns a request context for `method` and `uri` with the given header
+// name/value pairs.
+func newTestRequest(method, uri string, headers ...string) *fasthttp.RequestCtx {
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.Header.SetMethod(method)
+	ctx.Request.SetRequestURI(uri)
+	for i := 0; i+1 < len(headers); i += 2 {
+		ctx.Request.Header.Set(headers[i], headers[i+1])
+	}
+	return ctx
+}
+
+// newBenchmarkRouter returns a router serving GET /ping with the given global middleware.
+func newBenchmarkRouter(middleware ...xylium.Middleware) *xylium.Router {
+	router := newTestRouter(middleware...)
+	router.GET("/ping", func(c *xylium.Context) error {
+		return c.String(http.StatusOK, "pong")
+	})
+	return router
+}
+
+// newPingRequest returns a request context for GET /ping.
+func newPingRequest() *fasthttp.RequestCtx {
+	return newTestRequest(fasthttp.MethodGet, "/ping")
+}
+
+// newDisabledConnector returns a connector created with Config.Disabled.
+func newDisabledConnector(tb testing.TB) *Connector {
+	tb.Helper()
+	connector, err := New(Config{Disabled: true, AppLogger: discardLogger()})
+	if err != nil {
+		tb.Fatalf("New() error = %v", err)
+	}
+	return connector
+}
+
+// BenchmarkNoMiddleware is the baseline for BenchmarkOtelMiddlewareDisabled: with Config.Disabled,
+// the middleware must add no allocations per request.
+func BenchmarkNoMiddleware(b *testing.B) {
+	router := newBenchmarkRouter()
+	ctx := newPingRequest()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		router.Handler(ctx)
+	}
+}
+
+func BenchmarkOtelMiddlewareDisabled(b *testing.B) {
+	router := newBenchmarkRouter(newDisabledConnector(b).OtelMiddleware())
+	ctx := newPingRequest()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		router.Handler(ctx)
+	}
+}
+
+func TestOtelMiddlewareDisabledAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("allocation counts are not meaningful with -race")
+	}
+	baseline := newBenchmarkRouter()
+	disabled := newBenchmarkRouter(newDisabledConnector(t).OtelMiddleware())
+	ctx := newPingRequest()
+
+	want := testing.AllocsPerRun(100, func() { baseline.Handler(ctx) })
+	got := testing.AllocsPerRun(100, func() { disabled.Handler(ctx) })
+	if delta := got - want; delta > 0 {
+		t.Errorf("disabled middleware allocates %v times per request (%v with, %v without), want 0", delta, got, want)
+	}
+}
+
+func TestNetworkProtocolVersion(t *testing.T) {
+	tests := []struct {
+		protocol string
+		want     string
+	}{
+		{"HTTP/1.0", "1.0"},
+		{"HTTP/1.1", "1.1"},
+		{"HTTP/2.0", "2"},
+		{"HTTP/2", "2"},
+		{"HTTP/3.0", "3"},
+		{"HTTP/", ""},
+		{"", ""},
+		{"SPDY/3", ""},
+	}
+	for _, tt := range tests {
+		if got := networkProtocolVersion([]byte(tt.protocol)); got != tt.want {
+			t.Errorf("networkProtocolVersion(%q) = %q, want %q", tt.protocol, got, tt.want)
+		}
+	}
+}
+
+func TestDedupeAttributesLastValueWins(t *testing.T) {
+	attrs := []attribute.KeyValue{
+		attribute.String("http.route", "/users/:id"),
+		attribute.Int("http.response.status_code", 200),
+		attribute.String("http.route", "/users/{id}"),
+		attribute.String("user.id", "42"),
+		attribute.Int("http.response.status_code", 404),
+	}
+	got := dedupeAttributes(attrs)
+	want := []attribute.KeyValue{
+		attribute.String("http.route", "/users/{id}"),
+		attribute.Int("http.response.status_code", 404),
+		attribute.String("user.id", "42"),
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("dedupeAttributes() = %v, want %v", got, want)
+	}
+
+	unique := []attribute.KeyValue{attribute.String("a", "1"), attribute.String("b", "2")}
+	if got := dedupeAttributes(slices.Clone(unique)); !slices.Equal(got, unique) {
+		t.Errorf("dedupeAttributes() without duplicates = %v, want %v", got, unique)
+	}
+	if got := dedupeAttributes(nil); len(got) != 0 {
+		t.Errorf("dedupeAttributes(nil) = %v, want empty", got)
+	}
+}
+
+func BenchmarkDedupeAttributes(b *testing.B) {
+	// A typical server span attribute list with one user-supplied override.
+	base := []attribute.KeyValue{
+		attribute.String("http.request.method", "GET"),
+		attribute.String("url.path", "/users/42"),
+		attribute.String("url.scheme", "http"),
+		attribute.String("server.address", "localhost"),
+		attribute.Int("server.port", 8080),
+		attribute.String("client.address", "127.0.0.1"),
+		attribute.String("http.route", "/users/:id"),
+		attribute.String("user_agent.original", "bench"),
+		attribute.String("network.protocol.version", "1.1"),
+		attribute.String("http.route", "/users/{id}"),
+	}
+	attrs := make([]attribute.KeyValue, len(base))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		copy(attrs, base)
+		dedupeAttributes(attrs)
+	}
+}
+
+// serveTraced serves one request through a router using `connector`'s middleware and `handler`
+// on GET and OPTIONS /orders, after the `before` middleware. It returns every span `recorder`
+// has ended so far.
+func serveTraced(t *testing.T, connector *Connector, recorder *tracetest.SpanRecorder, cfg MiddlewareConfig, handler xylium.HandlerFunc, ctx *fasthttp.RequestCtx, before ...xylium.Middleware) []sdktrace.ReadOnlySpan {
+	t.Helper()
+	router := newTestRouter(append(before, connector.OtelMiddleware(cfg))...)
+	router.GET("/orders", handler)
+	router.OPTIONS("/orders", handler)
+	router.Handler(ctx)
+	return recorder.Ended()
+}
+
+// okHandler responds 200 with a fixed body.
+func okHandler(c *xylium.Context) error {
+	return c.String(http.StatusOK, "ok")
+}
+
+func TestOtelMiddlewareIgnoreMethods(t *testing.T) {
+	connector, recorder := NewTestConnector()
+	defer connector.Close()
+
+	var parent trace.SpanContext
+	handler := func(c *xylium.Context) error {
+		parent = trace.SpanContextFromContext(c.GoContext())
+		return c.NoContent(http.StatusNoContent)
+	}
+	cfg := MiddlewareConfig{IgnoreMethods: []string{"options"}}
+	ctx := newTestRequest(fasthttp.MethodOptions, "/orders", "traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	if spans := serveTraced(t, connector, recorder, cfg, handler, ctx); len(spans) != 0 {
+		t.Errorf("recorded %d spans for an ignored method, want 0", len(spans))
+	}
+	if parent.TraceID().String() != "0af7651916cd43dd8448eb211c80319c" || !parent.IsRemote() {
+		t.Errorf("handler span context = %+v, want the propagated remote parent", parent)
+	}
+
+	if spans := serveTraced(t, connector, recorder, cfg, handler, newTestRequest(fasthttp.MethodGet, "/orders")); len(spans) != 1 {
+		t.Errorf("recorded %d spans for a traced method, want 1", len(spans))
+	}
+}
+
+func TestOtelMiddlewareSlowRequestThreshold(t *testing.T) {
+	tests := []struct {
+		name     string
+		elapsed  time.Duration
+		wantSlow bool
+	}{
+		{name: "slow", elapsed: 250 * time.Millisecond, wantSlow: true},
+		{name: "fast", elapsed: 50 * time.Millisecond, wantSlow: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := newFakeClock()
+			connector, recorder := NewTestConnector(Config{TimeSource: clock.Now})
+			defer connector.Close()
+
+			handler := func(c *xylium.Context) error {
+				clock.Advance(tt.elapsed)
+				return okHandler(c)
+			}
+			cfg := MiddlewareConfig{SlowRequestThreshold: 100 * time.Millisecond}
+			spans := serveTraced(t, connector, recorder, cfg, handler, newTestRequest(fasthttp.MethodGet, "/orders"))
+			if len(spans) != 1 {
+				t.Fatalf("recorded %d spans, want 1", len(spans))
+			}
+			attrs := attributesByKey(spans[0].Attributes())
+			if got := attrs["xylium.slow_request"] == "true"; got != tt.wantSlow {
+				t.Errorf("xylium.slow_request set = %v, want %v (attributes: %v)", got, tt.wantSlow, attrs)
+			}
+			if tt.wantSlow && attrs["http.server.duration_ms"] != "250" {
+				t.Errorf("http.server.duration_ms = %q, want %q", attrs["http.server.duration_ms"], "250")
+			}
+		})
+	}
+}
+
+func TestOtelMiddlewareIncludeRequestID(t *testing.T) {
+	setRequestID := func(next xylium.HandlerFunc) xylium.HandlerFunc {
+		return func(c *xylium.Context) error {
+			c.Set(xylium.ContextKeyRequestID, "req-123")
+			return next(c)
+		}
+	}
+	disabled := false
+	tests := []struct {
+		name    string
+		include *bool
+		want    string
+	}{
+		{name: "default", include: nil, want: "req-123"},
+		{name: "disabled", include: &disabled, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			connector, recorder := NewTestConnector()
+			defer connector.Close()
+
+			cfg := MiddlewareConfig{IncludeRequestID: tt.include}
+			spans := serveTraced(t, connector, recorder, cfg, okHandler, newTestRequest(fasthttp.MethodGet, "/orders"), setRequestID)
+			if len(spans) != 1 {
+				t.Fatalf("recorded %d spans, want 1", len(spans))
+			}
+			if got := attributesByKey(spans[0].Attributes())["xylium.request_id"]; got != tt.want {
+				t.Errorf("xylium.request_id = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOtelMiddlewareRouteSampleOverride(t *testing.T) {
+	connector, recorder := NewTestConnector()
+	defer connector.Close()
+
+	connector.SetRouteSampleOverride("/orders", 0, time.Minute)
+	if spans := serveTraced(t, connector, recorder, MiddlewareConfig{}, okHandler, newTestRequest(fasthttp.MethodGet, "/orders")); len(spans) != 0 {
+		t.Errorf("recorded %d spans with a 0 ratio override, want 0", len(spans))
+	}
+
+	connector.SetRouteSampleOverride("/orders", 0, 0)
+	if spans := serveTraced(t, connector, recorder, MiddlewareConfig{}, okHandler, newTestRequest(fasthttp.MethodGet, "/orders")); len(spans) != 1 {
+		t.Errorf("recorded %d spans after removing the override, want 1", len(spans))
+	}
+}
+
+func TestOtelMiddlewareRouteSampleOverrideMatchesPath(t *testing.T) {
+	connector, recorder := NewTestConnector()
+	defer connector.Close()
+
+	router := newTestRouter(connector.OtelMiddleware())
+	router.GET("/orders/:id", okHandler)
+	connector.SetRouteSampleOverride("/orders/:id", 0, time.Minute) // A pattern never matches.
+	connector.SetRouteSampleOverride("/orders/1", 0, time.Minute)
+	router.Handler(newTestRequest(fasthttp.MethodGet, "/orders/1"))
+	router.Handler(newTestRequest(fasthttp.MethodGet, "/orders/2"))
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("recorded %d spans, want 1", len(spans))
+	}
+	if got := attributesByKey(spans[0].Attributes())["url.path"]; got != "/orders/2" {
+		t.Errorf("recorded span url.path = %q, want %q", got, "/orders/2")
+	}
+}
+
+func TestOtelMiddlewareTraceResponseHeader(t *testing.T) {
+	connector, recorder := NewTestConnector()
+	defer connector.Close()
+
+	ctx := newTestRequest(fasthttp.MethodGet, "/orders")
+	spans := serveTraced(t, connector, recorder, MiddlewareConfig{TraceResponseHeader: "X-Trace-Id"}, okHandler, ctx)
+	if len(spans) != 1 {
+		t.Fatalf("recorded %d spans, want 1", len(spans))
+	}
+	if got, want := string(ctx.Response.Header.Peek("X-Trace-Id")), spans[0].SpanContext().TraceID().String(); got != want {
+		t.Errorf("X-Trace-Id = %q, want %q", got, want)
+	}
+
+	connector.SetRouteSampleOverride("/orders", 0, time.Minute)
+	ctx = newTestRequest(fasthttp.MethodGet, "/orders")
+	serveTraced(t, connector, recorder, MiddlewareConfig{TraceResponseHeader: "X-Trace-Id"}, okHandler, ctx)
+	if got := ctx.Response.Header.Peek("X-Trace-Id"); got != nil {
+		t.Errorf("X-Trace-Id = %q for an unsampled request, want no header", got)
+	}
+}
+
+func TestOtelMiddlewareSkipIfCanceled(t *testing.T) {
+	connector, recorder := NewTestConnector()
+	defer connector.Close()
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	cancelRequest := func(next xylium.HandlerFunc) xylium.HandlerFunc {
+		return func(c *xylium.Context) error {
+			return next(c.WithGoContext(canceled))
+		}
+	}
+	handlerCalled := false
+	handler := func(c *xylium.Context) error {
+		handlerCalled = true
+		return okHandler(c)
+	}
+	spans := serveTraced(t, connector, recorder, MiddlewareConfig{SkipIfCanceled: true}, handler, newTestRequest(fasthttp.MethodGet, "/orders"), cancelRequest)
+	if handlerCalled {
+		t.Error("handler ran for a canceled request")
+	}
+	if len(spans) != 1 {
+		t.Fatalf("recorded %d spans, want 1", len(spans))
+	}
+	if got := attributesByKey(spans[0].Attributes())["http.request.aborted_before_handler"]; got != "true" {
+		t.Errorf("http.request.aborted_before_handler = %q, want %q", got, "true")
+	}
+}
+
+func TestOtelMiddlewareErrorStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler xylium.HandlerFunc
+		want    codes.Code
+	}{
+		{name: "ok", handler: okHandler, want: codes.Unset},
+		{name: "client error", handler: func(c *xylium.Context) error { return c.String(http.StatusNotFound, "missing") }, want: codes.Unset},
+		{name: "server error", handler: func(c *xylium.Context) error { return c.String(http.StatusInternalServerError, "boom") }, want: codes.Error},
+		{name: "handler error", handler: func(c *xylium.Context) error { return errors.New("boom") }, want: codes.Error},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			connector, recorder := NewTestConnector()
+			defer connector.Close()
+
+			spans := serveTraced(t, connector, recorder, MiddlewareConfig{}, tt.handler, newTestRequest(fasthttp.MethodGet, "/orders"))
+			if len(spans) != 1 {
+				t.Fatalf("recorded %d spans, want 1", len(spans))
+			}
+			if got := spans[0].Status().Code; got != tt.want {
+				t.Errorf("span status = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
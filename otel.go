@@ -9,6 +9,18 @@ import (
 	"errors"
 	"fmt"
 	"io" // For io.Closer
+	"maps"
+	"math/rand/v2"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/arwahdevops/xylium-core/src/xylium"
@@ -17,11 +29,18 @@ import (
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
 	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/log/global"
 	"go.opentelemetry.io/otel/propagation"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
 	"go.opentelemetry.io/otel/sdk/resource"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 	semconv "go.opentelemetry.io/otel/semconv/v1.26.0" // Using a recent semantic conventions version
 	"go.opentelemetry.io/otel/trace"
+	tracenoop "go.opentelemetry.io/otel/trace/noop"
+	"golang.org/x/oauth2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/oauth"
 )
 
 // ExporterType defines the type of OpenTelemetry trace exporter to configure.
@@ -40,18 +59,249 @@ const (
 	ExporterNone ExporterType = "none"
 )
 
+// ErrMissingOTLPEndpoint is returned when the OTLP gRPC exporter is selected but no endpoint
+// is configured, neither in OTLPConfig.Endpoint nor in the signal-specific
+// (OTEL_EXPORTER_OTLP_TRACES_ENDPOINT / OTEL_EXPORTER_OTLP_LOGS_ENDPOINT) or generic
+// OTEL_EXPORTER_OTLP_ENDPOINT environment variables. Use errors.Is to detect it.
+var ErrMissingOTLPEndpoint = errors.New("xylium-otel: OTLPConfig.Endpoint is required for the OTLP gRPC exporter")
+
+// Environment variables consulted when OTLPConfig.Endpoint is empty: the variable specific to
+// the signal being exported first, then the generic one.
+const (
+	envOTLPTracesEndpoint = "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"
+	envOTLPLogsEndpoint   = "OTEL_EXPORTER_OTLP_LOGS_ENDPOINT"
+	envOTLPEndpoint       = "OTEL_EXPORTER_OTLP_ENDPOINT"
+)
+
+// otlpEndpointFromEnv returns the OTLP endpoint configured via environment variables for a signal,
+// consulting signalKey (e.g., envOTLPTracesEndpoint) before the generic envOTLPEndpoint, as a
+// gRPC "host:port" target. For URL values, it also reports whether the scheme ("http")
+// implies an insecure connection. Returns an empty endpoint if neither variable is set.
+func otlpEndpointFromEnv(signalKey string) (endpoint string, source string, insecure bool) {
+	for _, key := range []string{signalKey, envOTLPEndpoint} {
+		value := strings.TrimSpace(os.Getenv(key))
+		if value == "" {
+			continue
+		}
+		if u, err := url.Parse(value); err == nil && u.Host != "" {
+			return u.Host, key, u.Scheme == "http"
+		}
+		return value, key, false
+	}
+	return "", "", false
+}
+
+// deriveServiceName returns a service name derived from the running binary's base name, or
+// from the last element of the main module path, along with a description of its source.
+// Returns an empty name if neither is available.
+func deriveServiceName() (name string, source string) {
+	if len(os.Args) > 0 {
+		if base := strings.TrimSuffix(filepath.Base(os.Args[0]), ".exe"); base != "" && base != "." && base != string(filepath.Separator) {
+			return base, "binary name"
+		}
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Path != "" && info.Main.Path != "command-line-arguments" {
+		return path.Base(info.Main.Path), "main module path"
+	}
+	return "", ""
+}
+
 // OTLPConfig holds configuration specific to the OTLP exporter.
 type OTLPConfig struct {
 	// Endpoint is the target URL for the OTLP gRPC exporter (e.g., "localhost:4317").
+	// If empty, the signal-specific environment variable (OTEL_EXPORTER_OTLP_TRACES_ENDPOINT for
+	// traces, OTEL_EXPORTER_OTLP_LOGS_ENDPOINT for logs) and then OTEL_EXPORTER_OTLP_ENDPOINT are
+	// used. An "http://" URL in those variables implies Insecure.
 	Endpoint string
 	// Insecure determines whether to use an insecure gRPC connection (e.g., for local testing).
 	// Defaults to false (secure connection) if not specified and Endpoint is set.
 	Insecure bool
 	// Headers is a map of additional headers to send with OTLP gRPC requests.
 	Headers map[string]string
+	// ExpandEnvHeaders, if true, expands `${VAR}` and `$VAR` references in Headers values with
+	// os.ExpandEnv when the exporter is created (e.g., "Bearer ${OTEL_TOKEN}"), keeping secrets
+	// out of code. Undefined variables expand to the empty string. Values are not re-read later;
+	// tokens that rotate while the process runs need TokenSource or ReloadExporter.
+	ExpandEnvHeaders bool
 	// Timeout for OTLP gRPC export operations.
 	// Defaults to 10 seconds if not set.
 	Timeout time.Duration
+	// Compression is the gRPC compressor used for OTLP exports, reducing network egress.
+	// Supported values: "gzip", or "" / "none" for no compression (default).
+	Compression string
+	// RetryConfig configures retrying of failed exports (e.g., during short collector outages).
+	// If nil, the SDK's default retry policy is used.
+	RetryConfig *OTLPRetryConfig
+	// DialOptions are additional gRPC dial options passed to the OTLP gRPC exporters (and
+	// ProbeCollector), e.g. grpc.WithContextDialer to route exports through a SOCKS proxy.
+	// Only valid when traces or logs use ExporterOTLPGRPC; New returns an error otherwise.
+	DialOptions []grpc.DialOption
+	// TokenSource, if set, supplies a fresh OAuth2 bearer token for every export RPC as the
+	// `Authorization: Bearer <token>` header, for managed collectors requiring short-lived tokens
+	// (e.g., GCP workload identity). Tokens are cached and refreshed by the TokenSource itself
+	// (wrap it with oauth2.ReuseTokenSource if it does not cache). Requires a TLS connection
+	// (Insecure must be false) and, like DialOptions, the OTLP gRPC exporter.
+	TokenSource oauth2.TokenSource
+	// HeaderProvider, if set, is called before every export RPC to supply additional headers,
+	// e.g. an authentication header carrying a short-lived token that the function refreshes
+	// (and caches) itself. It is called concurrently and on the export path, so it must be safe for
+	// concurrent use and fast. Its headers are sent in addition to Headers; avoid returning names
+	// that are also set there. Like DialOptions, it requires the OTLP gRPC exporter.
+	HeaderProvider func() map[string]string
+}
+
+// grpcDialOptions returns the gRPC dial options for OTLP exports: the user-supplied
+// DialOptions plus per-RPC OAuth2 credentials if a TokenSource is configured, and per-RPC
+// headers if a HeaderProvider is configured.
+func (o OTLPConfig) grpcDialOptions() []grpc.DialOption {
+	dialOptions := slices.Clone(o.DialOptions)
+	if o.TokenSource != nil {
+		dialOptions = append(dialOptions, grpc.WithPerRPCCredentials(oauth.TokenSource{TokenSource: o.TokenSource}))
+	}
+	if o.HeaderProvider != nil {
+		dialOptions = append(dialOptions, grpc.WithPerRPCCredentials(headerProviderCredentials{provider: o.HeaderProvider, requireTLS: !o.Insecure}))
+	}
+	return dialOptions
+}
+
+// headerProviderCredentials adapts OTLPConfig.HeaderProvider to credentials.PerRPCCredentials,
+// so that its headers are requested anew for every export RPC.
+type headerProviderCredentials struct {
+	provider   func() map[string]string
+	requireTLS bool
+}
+
+// GetRequestMetadata returns the headers supplied by the provider.
+// Implements credentials.PerRPCCredentials.
+func (c headerProviderCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return c.provider(), nil
+}
+
+// RequireTransportSecurity reports whether the headers may only be sent over TLS, which is the
+// case unless the exporter was explicitly configured as Insecure.
+// Implements credentials.PerRPCCredentials.
+func (c headerProviderCredentials) RequireTransportSecurity() bool {
+	return c.requireTLS
+}
+
+// headers returns the headers to send with OTLP exports: Headers, with environment variable
+// references expanded if ExpandEnvHeaders is true.
+func (o OTLPConfig) headers() map[string]string {
+	if !o.ExpandEnvHeaders || len(o.Headers) == 0 {
+		return o.Headers
+	}
+	expanded := make(map[string]string, len(o.Headers))
+	for name, value := range o.Headers {
+		expanded[name] = os.ExpandEnv(value)
+	}
+	return expanded
+}
+
+// validateOTLPConfig checks the settings of an OTLP configuration that cannot be verified by the
+// exporter itself. `field` names the configuration in error messages (e.g., "LogsConfig.OTLP").
+func validateOTLPConfig(otlp OTLPConfig, field string) error {
+	switch otlp.Compression {
+	case "", "none", "gzip":
+	default:
+		return fmt.Errorf("xylium-otel: unsupported %s.Compression '%s' (supported: \"gzip\", \"none\")", field, otlp.Compression)
+	}
+	if otlp.TokenSource != nil && otlp.Insecure {
+		return fmt.Errorf("xylium-otel: %s.TokenSource requires a secure connection (%s.Insecure must be false)", field, field)
+	}
+	return nil
+}
+
+// OTLPRetryConfig defines the retry/backoff policy for OTLP exports.
+// Zero durations fall back to the SDK defaults (5s initial interval, 30s max interval, 1m max elapsed time).
+type OTLPRetryConfig struct {
+	// Enabled indicates whether failed exports are retried.
+	Enabled bool
+	// InitialInterval is the time to wait after the first failure before retrying.
+	InitialInterval time.Duration
+	// MaxInterval is the upper bound on the backoff interval between retries.
+	MaxInterval time.Duration
+	// MaxElapsedTime is the maximum total time spent trying to send a batch, after which it is dropped.
+	MaxElapsedTime time.Duration
+}
+
+// withDefaults returns a copy of the retry config with zero durations replaced by the SDK defaults.
+func (r OTLPRetryConfig) withDefaults() OTLPRetryConfig {
+	if r.InitialInterval <= 0 {
+		r.InitialInterval = 5 * time.Second
+	}
+	if r.MaxInterval <= 0 {
+		r.MaxInterval = 30 * time.Second
+	}
+	if r.MaxElapsedTime <= 0 {
+		r.MaxElapsedTime = time.Minute
+	}
+	return r
+}
+
+// SpanLimitsConfig bounds the amount of data recorded on each span created by an
+// internally managed TracerProvider, protecting against unbounded memory growth under
+// heavy custom instrumentation. A zero value for any field means "use the SDK default"
+// (which honors the OTEL_SPAN_*_LIMIT environment variables); a negative value means unlimited.
+type SpanLimitsConfig struct {
+	// MaxAttributesPerSpan is the maximum number of attributes recorded on a span.
+	MaxAttributesPerSpan int
+	// MaxEventsPerSpan is the maximum number of events recorded on a span.
+	MaxEventsPerSpan int
+	// MaxLinksPerSpan is the maximum number of links recorded on a span.
+	MaxLinksPerSpan int
+	// AttributeValueLengthLimit is the maximum length of string attribute values; longer values are truncated.
+	AttributeValueLengthLimit int
+}
+
+// isSet reports whether any limit was explicitly configured.
+func (l SpanLimitsConfig) isSet() bool {
+	return l != SpanLimitsConfig{}
+}
+
+// toSDK converts the configuration to sdktrace.SpanLimits, starting from the SDK defaults.
+func (l SpanLimitsConfig) toSDK() sdktrace.SpanLimits {
+	limits := sdktrace.NewSpanLimits()
+	if l.MaxAttributesPerSpan != 0 {
+		limits.AttributeCountLimit = l.MaxAttributesPerSpan
+	}
+	if l.MaxEventsPerSpan != 0 {
+		limits.EventCountLimit = l.MaxEventsPerSpan
+	}
+	if l.MaxLinksPerSpan != 0 {
+		limits.LinkCountLimit = l.MaxLinksPerSpan
+	}
+	if l.AttributeValueLengthLimit != 0 {
+		limits.AttributeValueLengthLimit = l.AttributeValueLengthLimit
+	}
+	return limits
+}
+
+// BatchConfig tunes the batch processors of internally managed providers.
+type BatchConfig struct {
+	// BatchTimeout is the maximum delay between batch exports.
+	// Defaults to the SDK default (5 seconds) if not set.
+	BatchTimeout time.Duration
+	// TimeoutJitter, if greater than zero, adds a random duration in [0, TimeoutJitter) to
+	// BatchTimeout, drawn once per connector and shared by its traces and logs pipelines. This spreads out exports from many instances that
+	// start simultaneously (e.g., after a deploy), avoiding synchronized load spikes on the collector.
+	TimeoutJitter time.Duration
+}
+
+// effectiveTimeout returns the batch timeout with a newly drawn jitter applied. It reports false if
+// neither field is set, in which case the SDK default (honoring OTEL_BSP_SCHEDULE_DELAY) should be kept.
+// New calls it once and stores the result in Connector.batchTimeout.
+func (b BatchConfig) effectiveTimeout() (time.Duration, bool) {
+	if b.BatchTimeout <= 0 && b.TimeoutJitter <= 0 {
+		return 0, false
+	}
+	timeout := b.BatchTimeout
+	if timeout <= 0 {
+		timeout = sdktrace.DefaultScheduleDelay * time.Millisecond
+	}
+	if b.TimeoutJitter > 0 {
+		timeout += rand.N(b.TimeoutJitter)
+	}
+	return timeout, true
 }
 
 // Config holds all configuration options for initializing the OpenTelemetry Connector.
@@ -64,10 +314,18 @@ type Config struct {
 	// Required if not providing ExternalTracerProvider or ExternalSDKTracerProvider.
 	// Used to create the OTel resource.
 	ServiceName string
+	// AutoServiceName, if true, derives ServiceName when it is empty (and no external provider is
+	// given) instead of failing: the base name of the running binary (os.Args[0]) is used, or the
+	// last element of the main Go module path if that is unavailable. The derived name is logged.
+	AutoServiceName bool
 	// ServiceVersion is the version of the service, e.g., "v1.2.3". Optional.
 	ServiceVersion string
 	// Environment is the deployment environment, e.g., "production", "staging". Optional.
 	Environment string
+	// BuildInfo holds build metadata (e.g., {"git_sha": "4f2a9c1", "time": "2026-10-01T12:00:00Z"})
+	// added to the service resource as `service.build.<key>` attributes, so every span and log
+	// record of the process identifies its build without per-span attributes. Optional.
+	BuildInfo map[string]string
 
 	// Exporter defines the type of trace exporter to initialize if an internal
 	// TracerProvider is being created.
@@ -75,7 +333,14 @@ type Config struct {
 	// unless an external provider is specified.
 	Exporter ExporterType
 	// OTLP holds configuration for the OTLP gRPC exporter if Exporter is ExporterOTLPGRPC.
+	// It is also used by the logs signal unless Logs.OTLP overrides it.
 	OTLP OTLPConfig
+	// StdoutWriter, if set, is the destination of the ExporterStdout trace and log exporters
+	// (e.g., a bytes.Buffer in tests, or a file) instead of os.Stdout.
+	StdoutWriter io.Writer
+	// StdoutPrettyPrint determines whether the ExporterStdout exporters indent their JSON output.
+	// Set it to false for compact, one-document-per-line output. Defaults to true.
+	StdoutPrettyPrint *bool // Pointer to distinguish between not set (use default true) and explicitly false.
 
 	// ExternalTracerProvider allows providing a pre-configured trace.TracerProvider.
 	// If set, the connector will use this provider and will not manage its lifecycle
@@ -86,6 +351,15 @@ type Config struct {
 	// Similar to ExternalTracerProvider but for the SDK-specific type. Takes precedence over ExternalTracerProvider.
 	// If ManageGlobalProviders is true, this provider will be set as the global OTel provider.
 	ExternalSDKTracerProvider *sdktrace.TracerProvider
+	// UseGlobalProvider, if true, makes the connector deliberately use the global OTel
+	// TracerProvider (otel.GetTracerProvider()) installed by another library, such as OTel
+	// operator auto-instrumentation, instead of creating its own. The connector does not manage
+	// that provider's lifecycle, and internal exporter configuration is ignored. Because the global
+	// provider delegates, it may also be installed after New.
+	//
+	// TracerProvider precedence: ExternalSDKTracerProvider, then ExternalTracerProvider, then
+	// UseGlobalProvider, then an internal provider built from Exporter (NoOp if Exporter is 'none').
+	UseGlobalProvider bool
 
 	// ManageGlobalProviders determines if this connector should manage (set) the global
 	// OTel TracerProvider and TextMapPropagator using otel.SetTracerProvider and otel.SetTextMapPropagator.
@@ -100,8 +374,49 @@ type Config struct {
 	// If ManageGlobalProviders is true, this propagator will be set as the global OTel propagator.
 	Propagator propagation.TextMapPropagator
 	// Sampler defines the sampling strategy for traces.
-	// If nil, ParentBased(AlwaysSample()) is used as a default.
+	// If nil, the sampler described by SamplingStrategy is used, or ParentBased(AlwaysSample())
+	// if SamplingStrategy is also nil.
 	Sampler sdktrace.Sampler
+	// SamplingStrategy declaratively configures a ParentBased sampler (root ratio plus the behavior
+	// for sampled/not-sampled remote and local parents). It cannot be combined with Sampler.
+	SamplingStrategy *SamplingStrategy
+	// SamplerRandSource, if set, makes sampling reproducible (e.g., in unit tests). It seeds the
+	// trace and span ID generator of an internally managed TracerProvider, so ratio-based samplers
+	// (which decide by trace ID) make the same keep/drop decisions on every run, and it drives the
+	// random draw of per-route sampling overrides (see SetRouteSampleOverride).
+	// Use a fixed-seed source such as rand.NewPCG(1, 2) from math/rand/v2. Access is serialized
+	// internally, so the source does not need to be safe for concurrent use.
+	// Not intended for production: generated IDs are only as unique as the source is random.
+	SamplerRandSource rand.Source
+	// TimeSource, if set, supplies the start and end timestamps of the server spans created by the
+	// middleware (via trace.WithTimestamp) instead of time.Now, so tests can simulate slow handlers
+	// with a fake clock and assert on span durations deterministically. Spans created directly
+	// through GetTracer are unaffected. Not intended for production use.
+	TimeSource func() time.Time
+	// SpanProcessors are additional span processors registered on an internally managed
+	// TracerProvider alongside the exporting batcher (e.g., an OnStart processor stamping every span
+	// with `deployment.region` or a build SHA). They are registered before the batcher, in order.
+	SpanProcessors []sdktrace.SpanProcessor
+	// DropSpanPredicate, if set, is evaluated on every completed span of an internally managed
+	// TracerProvider; spans for which it returns true are not exported (e.g., health-check spans
+	// that slipped past the middleware Filter, or spans shorter than 1ms). It operates on the
+	// actual span data, so it must be fast and safe for concurrent use. Custom SpanProcessors
+	// still observe every span.
+	DropSpanPredicate func(s sdktrace.ReadOnlySpan) bool
+	// KeepRecentSpans, if positive, retains summaries of the last KeepRecentSpans completed spans
+	// of an internally managed TracerProvider in a bounded in-memory ring buffer, queryable at
+	// runtime with Connector.RecentSpans. Defaults to 0 (disabled).
+	KeepRecentSpans int
+	// SpanLimits bounds attributes, events, links, and attribute value length per span.
+	// Only applicable to an internally managed TracerProvider. Zero values use the SDK defaults.
+	SpanLimits SpanLimitsConfig
+
+	// BatchConfig tunes batch export timing (including jitter) for internally managed providers.
+	BatchConfig BatchConfig
+
+	// Logs holds configuration for the OpenTelemetry logs signal (managed LoggerProvider).
+	// Disabled by default.
+	Logs LogsConfig
 
 	// ShutdownTimeout is the duration to wait for the managed TracerProvider to shut down gracefully.
 	// Defaults to 5 seconds. Only applicable if the connector manages the TracerProvider lifecycle.
@@ -109,16 +424,81 @@ type Config struct {
 	// Disabled, if true, completely disables OpenTelemetry integration by this connector.
 	// The connector will operate in a no-op mode.
 	Disabled bool
+	// FailOpen, if true, makes tracing best-effort at startup: if the internal trace exporter cannot
+	// be created (e.g., the collector's DNS name is not resolvable yet), New logs a warning and
+	// returns a NoOp connector (which still propagates trace context) instead of an error.
+	// Likewise, a failing log exporter disables only the logs signal. Configuration errors are still
+	// returned, e.g. a missing ServiceName, an unsupported exporter type, or a missing OTLP
+	// endpoint (ErrMissingOTLPEndpoint).
+	FailOpen bool
+	// SemconvStabilityMode selects the HTTP semantic conventions emitted by the middleware:
+	// SemconvStabilityHTTP (stable keys such as `http.request.method`, the default),
+	// SemconvStabilityHTTPDup (stable and legacy keys such as `http.method`), or SemconvStabilityOld
+	// (legacy keys only), easing migration for older collectors and backends. If empty, the
+	// OTEL_SEMCONV_STABILITY_OPT_IN environment variable ("http" or "http/dup") is honored.
+	SemconvStabilityMode SemconvStabilityMode
+	// SilentInit, if true, lowers the connector's detailed initialization logs (exporter, provider,
+	// and propagator setup) from Info to Debug level, keeping only warnings and errors visible by
+	// default. Useful for services that restart frequently. Runtime logs are unaffected.
+	SilentInit bool
+}
+
+// initLog logs an initialization message at Info level, or at Debug level if SilentInit is set.
+func (cfg Config) initLog(msg string) {
+	if cfg.SilentInit {
+		cfg.AppLogger.Debug(msg)
+		return
+	}
+	cfg.AppLogger.Info(msg)
+}
+
+// initLogf is the formatted variant of initLog.
+func (cfg Config) initLogf(format string, args ...any) {
+	if cfg.SilentInit {
+		cfg.AppLogger.Debugf(format, args...)
+		return
+	}
+	cfg.AppLogger.Infof(format, args...)
+}
+
+// stdoutPrettyPrint reports whether the stdout exporters should pretty-print (see StdoutPrettyPrint).
+func (cfg Config) stdoutPrettyPrint() bool {
+	return cfg.StdoutPrettyPrint == nil || *cfg.StdoutPrettyPrint
 }
 
 // Connector is the Xylium-aware wrapper for OpenTelemetry functionality.
 // It manages the TracerProvider, Propagator, and provides middleware for instrumentation.
 type Connector struct {
-	config         Config
-	tracerProvider *sdktrace.TracerProvider // Holds the SDK TracerProvider if managed internally
-	tracer         trace.Tracer             // Tracer instance for this connector's middleware/operations
-	propagator     propagation.TextMapPropagator
-	isNoOp         bool
+	config             Config
+	tracerProvider     *sdktrace.TracerProvider // Holds the SDK TracerProvider if managed internally
+	receivedSpans      []sdktrace.SpanProcessor // Processors fed with spans received by OTLPReceiverHandler, if the TracerProvider is managed internally
+	reloadableExporter *reloadableSpanExporter  // Swappable innermost exporter of the internally managed TracerProvider, if any
+	sampler            *connectorSampler        // Swappable sampler of the internally managed TracerProvider, if any
+	stats              *exportStats             // Export pipeline counters of the internally managed TracerProvider, if any
+	recentSpans        *recentSpansProcessor    // Ring buffer of recently completed spans, if Config.KeepRecentSpans is positive
+	loggerProvider     *sdklog.LoggerProvider   // Holds the SDK LoggerProvider if logs are enabled
+	resource           *resource.Resource       // Resource shared by all internally managed providers (see buildResource)
+	tracer             trace.Tracer             // Tracer instance for this connector's middleware/operations
+	propagator         propagation.TextMapPropagator
+	propagatorNames    []string // Names of the active propagators, resolved in New (see ConfigSummary)
+	isNoOp             bool
+
+	randFloat64 func() float64   // Random draw for per-route sampling overrides (see Config.SamplerRandSource)
+	seededRand  *lockedRand      // Config.SamplerRandSource, shared by randFloat64 and the ID generator, if set
+	now         func() time.Time // Clock for middleware span timestamps (see Config.TimeSource)
+
+	batchTimeout    time.Duration // Batch timeout with jitter drawn once, shared by traces and logs (see BatchConfig)
+	batchTimeoutSet bool          // Whether batchTimeout overrides the SDK default
+
+	routeOverridesMu sync.RWMutex                   // Guards routeOverrides
+	routeOverrides   map[string]routeSampleOverride // Temporary per-route sampling overrides (see SetRouteSampleOverride)
+	pinnedTraces     pinnedTraceIDs                 // Force-sampled trace IDs (see PinTraceID)
+
+	reloadMu sync.Mutex // Serializes ReloadExporter calls and guards config.OTLP and closed
+	closed   bool       // Set by Close; ReloadExporter fails afterwards
+
+	activeServerSpans atomic.Int64 // Middleware server spans started but not yet ended (see Drain)
+	closeOnce         sync.Once    // Makes Close idempotent
 }
 
 // New creates and initializes a new OpenTelemetry Connector instance based on the provided configuration.
@@ -130,7 +510,7 @@ func New(cfg Config) (*Connector, error) {
 	if cfg.Disabled {
 		// If AppLogger is available even when disabled, log it.
 		if cfg.AppLogger != nil {
-			cfg.AppLogger.Info("xylium-otel: OpenTelemetry integration is explicitly disabled by configuration. Connector will be NoOp.")
+			cfg.initLog("xylium-otel: OpenTelemetry integration is explicitly disabled by configuration. Connector will be NoOp.")
 		} else {
 			fmt.Println("[xylium-otel-bootstrap] OpenTelemetry integration is explicitly disabled by configuration. Connector will be NoOp.")
 		}
@@ -141,8 +521,14 @@ func New(cfg Config) (*Connector, error) {
 	if cfg.AppLogger == nil {
 		return nil, errors.New("xylium-otel: Config.AppLogger is required for the OTel connector")
 	}
-	if cfg.ServiceName == "" && cfg.ExternalTracerProvider == nil && cfg.ExternalSDKTracerProvider == nil {
-		return nil, errors.New("xylium-otel: Config.ServiceName is required when not providing an ExternalTracerProvider or ExternalSDKTracerProvider")
+	if cfg.ServiceName == "" && cfg.AutoServiceName && cfg.ExternalTracerProvider == nil && cfg.ExternalSDKTracerProvider == nil {
+		if name, source := deriveServiceName(); name != "" {
+			cfg.ServiceName = name
+			cfg.initLogf("xylium-otel: Config.ServiceName not specified, derived '%s' from the %s (AutoServiceName is true).", name, source)
+		}
+	}
+	if cfg.ServiceName == "" && cfg.ExternalTracerProvider == nil && cfg.ExternalSDKTracerProvider == nil && !cfg.UseGlobalProvider {
+		return nil, errors.New("xylium-otel: Config.ServiceName is required when not providing an ExternalTracerProvider or ExternalSDKTracerProvider, or setting UseGlobalProvider")
 	}
 
 	// Apply defaults
@@ -153,12 +539,36 @@ func New(cfg Config) (*Connector, error) {
 		} else {
 			cfg.Exporter = ExporterStdout // Default to Stdout for non-release modes for easier local dev
 		}
-		cfg.AppLogger.Infof("xylium-otel: Config.Exporter not specified, defaulted to '%s' (Xylium mode: '%s').", cfg.Exporter, currentMode)
+		cfg.initLogf("xylium-otel: Config.Exporter not specified, defaulted to '%s' (Xylium mode: '%s').", cfg.Exporter, currentMode)
+	}
+
+	if err := validateOTLPConfig(cfg.OTLP, "OTLPConfig"); err != nil {
+		return nil, err
 	}
 
 	if cfg.ShutdownTimeout <= 0 {
 		cfg.ShutdownTimeout = 5 * time.Second
 	}
+	if cfg.SemconvStabilityMode == "" {
+		if cfg.SemconvStabilityMode = semconvStabilityModeFromEnv(); cfg.SemconvStabilityMode == "" {
+			cfg.SemconvStabilityMode = SemconvStabilityHTTP
+		}
+	}
+	switch cfg.SemconvStabilityMode {
+	case SemconvStabilityHTTP, SemconvStabilityHTTPDup, SemconvStabilityOld:
+	default:
+		return nil, fmt.Errorf("xylium-otel: unsupported Config.SemconvStabilityMode '%s' (supported: \"http\", \"http/dup\", \"old\")", cfg.SemconvStabilityMode)
+	}
+	if cfg.SamplingStrategy != nil {
+		if cfg.Sampler != nil {
+			return nil, errors.New("xylium-otel: Config.Sampler and Config.SamplingStrategy cannot both be set")
+		}
+		strategySampler, err := cfg.SamplingStrategy.sampler()
+		if err != nil {
+			return nil, err
+		}
+		cfg.Sampler = strategySampler
+	}
 	if cfg.Sampler == nil {
 		cfg.Sampler = sdktrace.ParentBased(sdktrace.AlwaysSample())
 	}
@@ -166,48 +576,140 @@ func New(cfg Config) (*Connector, error) {
 		manageGlobalDefault := true
 		cfg.ManageGlobalProviders = &manageGlobalDefault
 	}
+	if cfg.Logs.Enabled && cfg.Logs.Exporter == "" {
+		cfg.Logs.Exporter = cfg.Exporter
+	}
+	if len(cfg.OTLP.DialOptions) > 0 && cfg.Exporter != ExporterOTLPGRPC && !(cfg.Logs.Enabled && cfg.Logs.Exporter == ExporterOTLPGRPC) {
+		return nil, fmt.Errorf("xylium-otel: OTLPConfig.DialOptions requires the '%s' exporter (configured: '%s')", ExporterOTLPGRPC, cfg.Exporter)
+	}
+	if cfg.OTLP.TokenSource != nil && cfg.Exporter != ExporterOTLPGRPC && !(cfg.Logs.Enabled && cfg.Logs.Exporter == ExporterOTLPGRPC) {
+		return nil, fmt.Errorf("xylium-otel: OTLPConfig.TokenSource requires the '%s' exporter (configured: '%s')", ExporterOTLPGRPC, cfg.Exporter)
+	}
+	if cfg.OTLP.HeaderProvider != nil && cfg.Exporter != ExporterOTLPGRPC && !(cfg.Logs.Enabled && cfg.Logs.Exporter == ExporterOTLPGRPC) {
+		return nil, fmt.Errorf("xylium-otel: OTLPConfig.HeaderProvider requires the '%s' exporter (configured: '%s')", ExporterOTLPGRPC, cfg.Exporter)
+	}
+	if cfg.Logs.OTLP != nil && !(cfg.Logs.Enabled && cfg.Logs.Exporter == ExporterOTLPGRPC) {
+		return nil, fmt.Errorf("xylium-otel: LogsConfig.OTLP requires logs to be enabled with the '%s' exporter (configured: '%s')", ExporterOTLPGRPC, cfg.Logs.Exporter)
+	}
+	// Resolve the logs OTLP configuration before the traces endpoint is filled in from the
+	// traces-specific environment variable, so each signal honors its own variable.
+	if cfg.Logs.Enabled && cfg.Logs.Exporter == ExporterOTLPGRPC {
+		logsOTLP := cfg.OTLP
+		if cfg.Logs.OTLP != nil {
+			logsOTLP = *cfg.Logs.OTLP
+			if err := validateOTLPConfig(logsOTLP, "LogsConfig.OTLP"); err != nil {
+				return nil, err
+			}
+		}
+		if logsOTLP.Endpoint == "" {
+			if endpoint, source, insecure := otlpEndpointFromEnv(envOTLPLogsEndpoint); endpoint != "" {
+				logsOTLP.Endpoint = endpoint
+				logsOTLP.Insecure = logsOTLP.Insecure || insecure
+				cfg.initLogf("xylium-otel: OTLP endpoint for logs not specified, using '%s' from %s.", endpoint, source)
+			}
+		}
+		if logsOTLP.Timeout <= 0 {
+			logsOTLP.Timeout = 10 * time.Second
+		}
+		cfg.Logs.OTLP = &logsOTLP
+	}
+	if cfg.OTLP.Endpoint == "" && cfg.Exporter == ExporterOTLPGRPC {
+		if endpoint, source, insecure := otlpEndpointFromEnv(envOTLPTracesEndpoint); endpoint != "" {
+			cfg.OTLP.Endpoint = endpoint
+			cfg.OTLP.Insecure = cfg.OTLP.Insecure || insecure
+			cfg.initLogf("xylium-otel: OTLPConfig.Endpoint not specified, using '%s' from %s.", endpoint, source)
+		}
+	}
 	if cfg.OTLP.Timeout <= 0 && cfg.Exporter == ExporterOTLPGRPC {
 		cfg.OTLP.Timeout = 10 * time.Second
 	}
+	// Reject exporter configurations that can never work before any exporter is created, so
+	// FailOpen only covers runtime failures (e.g., an unresolvable collector) and not these.
+	internalTraces := cfg.ExternalSDKTracerProvider == nil && cfg.ExternalTracerProvider == nil && !cfg.UseGlobalProvider
+	if internalTraces {
+		switch cfg.Exporter {
+		case ExporterOTLPGRPC, ExporterStdout, ExporterNone, exporterDiscard:
+		default:
+			return nil, fmt.Errorf("xylium-otel: unsupported Config.Exporter '%s' (supported: \"%s\", \"%s\", \"%s\")", cfg.Exporter, ExporterOTLPGRPC, ExporterStdout, ExporterNone)
+		}
+		if cfg.Exporter == ExporterOTLPGRPC && cfg.OTLP.Endpoint == "" {
+			return nil, ErrMissingOTLPEndpoint
+		}
+	}
+	if cfg.Logs.Enabled {
+		switch cfg.Logs.Exporter {
+		case ExporterOTLPGRPC, ExporterStdout, ExporterNone:
+		default:
+			return nil, fmt.Errorf("xylium-otel: unsupported LogsConfig.Exporter '%s' (supported: \"%s\", \"%s\", \"%s\")", cfg.Logs.Exporter, ExporterOTLPGRPC, ExporterStdout, ExporterNone)
+		}
+		if cfg.Logs.Exporter == ExporterOTLPGRPC && cfg.Logs.OTLP.Endpoint == "" {
+			return nil, ErrMissingOTLPEndpoint
+		}
+	}
 
 	c := &Connector{
-		config: cfg,
-		isNoOp: false, // Assume not NoOp initially
+		config:      cfg,
+		isNoOp:      false, // Assume not NoOp initially
+		randFloat64: rand.Float64,
+		now:         time.Now,
+	}
+	if cfg.TimeSource != nil {
+		c.now = cfg.TimeSource
+	}
+	c.batchTimeout, c.batchTimeoutSet = cfg.BatchConfig.effectiveTimeout()
+	if cfg.SamplerRandSource != nil {
+		// A single lockedRand serializes every draw from the source, whether from the middleware
+		// or the TracerProvider's ID generator.
+		c.seededRand = newLockedRand(cfg.SamplerRandSource)
+		c.randFloat64 = c.seededRand.Float64
 	}
 
 	// Determine TracerProvider
 	var actualTracerProvider trace.TracerProvider // This will be the provider used, either global or internal
 	if cfg.ExternalSDKTracerProvider != nil {
-		cfg.AppLogger.Info("xylium-otel: Using pre-configured external *sdktrace.TracerProvider.")
+		cfg.initLog("xylium-otel: Using pre-configured external *sdktrace.TracerProvider.")
 		actualTracerProvider = cfg.ExternalSDKTracerProvider
 		// No internal management of c.tracerProvider, as it's external.
 		// Global setting depends on ManageGlobalProviders.
 		if *c.config.ManageGlobalProviders {
 			otel.SetTracerProvider(cfg.ExternalSDKTracerProvider)
-			cfg.AppLogger.Info("xylium-otel: External *sdktrace.TracerProvider set as global OTel provider.")
+			cfg.initLog("xylium-otel: External *sdktrace.TracerProvider set as global OTel provider.")
 		}
 	} else if cfg.ExternalTracerProvider != nil {
-		cfg.AppLogger.Info("xylium-otel: Using pre-configured external trace.TracerProvider.")
+		cfg.initLog("xylium-otel: Using pre-configured external trace.TracerProvider.")
 		actualTracerProvider = cfg.ExternalTracerProvider
 		if *c.config.ManageGlobalProviders {
 			otel.SetTracerProvider(cfg.ExternalTracerProvider)
-			cfg.AppLogger.Info("xylium-otel: External trace.TracerProvider set as global OTel provider.")
+			cfg.initLog("xylium-otel: External trace.TracerProvider set as global OTel provider.")
 		}
+	} else if cfg.UseGlobalProvider {
+		cfg.initLog("xylium-otel: Using the global OTel TracerProvider (UseGlobalProvider is true). Its lifecycle is not managed by this connector.")
+		actualTracerProvider = otel.GetTracerProvider()
 	} else if cfg.Exporter != ExporterNone {
 		tp, err := c.initInternalTracerProvider() // initInternalTracerProvider now takes Connector receiver
 		if err != nil {
-			return nil, fmt.Errorf("xylium-otel: failed to initialize internal TracerProvider: %w", err)
-		}
-		c.tracerProvider = tp // Store the internally managed SDK TracerProvider
-		actualTracerProvider = tp
-		if *c.config.ManageGlobalProviders {
-			otel.SetTracerProvider(tp)
-			cfg.AppLogger.Infof("xylium-otel: Internal TracerProvider (Exporter: %s) initialized and set as global OTel provider.", cfg.Exporter)
+			if !cfg.FailOpen {
+				return nil, fmt.Errorf("xylium-otel: failed to initialize internal TracerProvider: %w", err)
+			}
+			// Best-effort tracing: continue startup without spans rather than failing.
+			cfg.AppLogger.Warnf("xylium-otel: Failed to initialize internal TracerProvider (Exporter: %s): %v. FailOpen is set, so the connector will be NoOp for tracing.", cfg.Exporter, err)
+			c.isNoOp = true
+			c.sampler = nil // The sampler belongs to the TracerProvider that was not created.
+			c.stats = nil
+			c.reloadableExporter = nil
+			actualTracerProvider = otel.GetTracerProvider() // Fallback to global (which might be NoOp)
 		} else {
-			cfg.AppLogger.Infof("xylium-otel: Internal TracerProvider (Exporter: %s) initialized but NOT set as global (ManageGlobalProviders is false).", cfg.Exporter)
+			c.tracerProvider = tp // Store the internally managed SDK TracerProvider
+			actualTracerProvider = tp
+			if *c.config.ManageGlobalProviders {
+				otel.SetTracerProvider(tp)
+				cfg.initLogf("xylium-otel: Internal TracerProvider (Exporter: %s) initialized and set as global OTel provider.", cfg.Exporter)
+			} else {
+				cfg.initLogf("xylium-otel: Internal TracerProvider (Exporter: %s) initialized but NOT set as global (ManageGlobalProviders is false).", cfg.Exporter)
+			}
 		}
 	} else {
-		cfg.AppLogger.Info("xylium-otel: No external TracerProvider and Exporter is 'none'. Connector will be NoOp for tracing unless a global provider is set elsewhere.")
+		cfg.initLog("xylium-otel: No external TracerProvider and Exporter is 'none'. Connector will be NoOp for tracing unless a global provider is set elsewhere.")
 		c.isNoOp = true
 		actualTracerProvider = otel.GetTracerProvider() // Fallback to global (which might be NoOp)
 	}
@@ -217,9 +719,9 @@ func New(cfg Config) (*Connector, error) {
 		c.propagator = cfg.Propagator
 		if *c.config.ManageGlobalProviders {
 			otel.SetTextMapPropagator(c.propagator)
-			cfg.AppLogger.Info("xylium-otel: Custom Propagator configured and set as global OTel propagator.")
+			cfg.initLog("xylium-otel: Custom Propagator configured and set as global OTel propagator.")
 		} else {
-			cfg.AppLogger.Info("xylium-otel: Custom Propagator configured but NOT set as global (ManageGlobalProviders is false).")
+			cfg.initLog("xylium-otel: Custom Propagator configured but NOT set as global (ManageGlobalProviders is false).")
 		}
 	} else {
 		c.propagator = propagation.NewCompositeTextMapPropagator(
@@ -228,9 +730,42 @@ func New(cfg Config) (*Connector, error) {
 		)
 		if *c.config.ManageGlobalProviders {
 			otel.SetTextMapPropagator(c.propagator)
-			cfg.AppLogger.Info("xylium-otel: Default Propagator (TraceContext & Baggage) set as global OTel propagator.")
+			cfg.initLog("xylium-otel: Default Propagator (TraceContext & Baggage) set as global OTel propagator.")
+		} else {
+			cfg.initLog("xylium-otel: Default Propagator (TraceContext & Baggage) configured but NOT set as global (ManageGlobalProviders is false).")
+		}
+	}
+	c.propagatorNames = propagatorNames(c.propagator)
+
+	// Setup the LoggerProvider if the logs signal is enabled.
+	if cfg.Logs.Enabled {
+		if cfg.Logs.Exporter == ExporterNone {
+			cfg.AppLogger.Warn("xylium-otel: Logs are enabled but the log exporter is 'none'. No LoggerProvider will be initialized.")
 		} else {
-			cfg.AppLogger.Info("xylium-otel: Default Propagator (TraceContext & Baggage) configured but NOT set as global (ManageGlobalProviders is false).")
+			lp, err := c.initInternalLoggerProvider()
+			switch {
+			case err != nil && cfg.FailOpen:
+				// Best-effort logs: keep tracing and continue startup without the logs signal.
+				cfg.AppLogger.Warnf("xylium-otel: Failed to initialize internal LoggerProvider (Exporter: %s): %v. FailOpen is set, so logs will not be exported.", cfg.Logs.Exporter, err)
+			case err != nil:
+				if c.tracerProvider != nil {
+					// Release the already initialized TracerProvider to prevent leaks.
+					shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+					defer cancel()
+					if serr := c.tracerProvider.Shutdown(shutdownCtx); serr != nil {
+						cfg.AppLogger.Errorf("xylium-otel: Failed to shutdown TracerProvider after LoggerProvider initialization error: %v", serr)
+					}
+				}
+				return nil, fmt.Errorf("xylium-otel: failed to initialize internal LoggerProvider: %w", err)
+			default:
+				c.loggerProvider = lp
+				if *c.config.ManageGlobalProviders {
+					global.SetLoggerProvider(lp)
+					cfg.initLogf("xylium-otel: Internal LoggerProvider (Exporter: %s) initialized and set as global OTel logger provider.", cfg.Logs.Exporter)
+				} else {
+					cfg.initLogf("xylium-otel: Internal LoggerProvider (Exporter: %s) initialized but NOT set as global (ManageGlobalProviders is false).", cfg.Logs.Exporter)
+				}
+			}
 		}
 	}
 
@@ -238,13 +773,13 @@ func New(cfg Config) (*Connector, error) {
 	// Use a distinct name for the connector's own tracer (used by middleware).
 	// If ManageGlobalProviders is false, this tracer comes from the internal TP,
 	// otherwise from the (now potentially set) global TP.
-	c.tracer = actualTracerProvider.Tracer("xylium-otel-connector", trace.WithInstrumentationVersion("xylium-otel/vNext")) // TODO: Add actual version
+	c.tracer = actualTracerProvider.Tracer("xylium-otel-connector", trace.WithInstrumentationVersion(Version))
 
 	if c.isNoOp {
 		cfg.AppLogger.Warn("xylium-otel: Connector initialized in NoOp mode. Tracing middleware will be a pass-through.")
 	}
 
-	cfg.AppLogger.Info("xylium-otel: Connector initialization complete.")
+	cfg.initLog("xylium-otel: Connector initialization complete.")
 	return c, nil
 }
 
@@ -258,45 +793,146 @@ func (c *Connector) initInternalTracerProvider() (*sdktrace.TracerProvider, erro
 	c.config.AppLogger.Debugf("xylium-otel: Initializing internal OTel exporter of type '%s'.", c.config.Exporter)
 
 	switch c.config.Exporter {
-	case ExporterOTLPGRPC:
-		if c.config.OTLP.Endpoint == "" {
-			return nil, errors.New("xylium-otel: OTLPConfig.Endpoint is required for OTLP gRPC exporter")
-		}
-		opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(c.config.OTLP.Endpoint)}
-		if c.config.OTLP.Insecure {
-			opts = append(opts, otlptracegrpc.WithInsecure())
-		}
-		if len(c.config.OTLP.Headers) > 0 {
-			opts = append(opts, otlptracegrpc.WithHeaders(c.config.OTLP.Headers))
-		}
-		if c.config.OTLP.Timeout > 0 {
-			opts = append(opts, otlptracegrpc.WithTimeout(c.config.OTLP.Timeout))
-		}
-
-		// Create context for exporter creation, can be short-lived.
-		exporterCtx, cancel := context.WithTimeout(context.Background(), c.config.OTLP.Timeout) // Use configured timeout or a default
-		defer cancel()
+	case exporterDiscard:
+		exporter = tracetest.NewNoopExporter()
 
-		exporter, err = otlptracegrpc.New(exporterCtx, opts...)
+	case ExporterOTLPGRPC:
+		exporter, err = c.newOTLPTraceExporter(c.config.OTLP)
 		if err != nil {
-			return nil, fmt.Errorf("xylium-otel: creating OTLP gRPC exporter to '%s': %w", c.config.OTLP.Endpoint, err)
+			return nil, err
 		}
-		c.config.AppLogger.Infof("xylium-otel: OTLP gRPC exporter configured for endpoint: %s (Insecure: %t, Timeout: %v).", c.config.OTLP.Endpoint, c.config.OTLP.Insecure, c.config.OTLP.Timeout)
 
 	case ExporterStdout:
-		exporter, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+		var stdoutOpts []stdouttrace.Option
+		if c.config.StdoutWriter != nil {
+			stdoutOpts = append(stdoutOpts, stdouttrace.WithWriter(c.config.StdoutWriter))
+		}
+		if c.config.stdoutPrettyPrint() {
+			stdoutOpts = append(stdoutOpts, stdouttrace.WithPrettyPrint())
+		}
+		exporter, err = stdouttrace.New(stdoutOpts...)
 		if err != nil {
 			return nil, fmt.Errorf("xylium-otel: creating stdout trace exporter: %w", err)
 		}
-		c.config.AppLogger.Info("xylium-otel: Stdout trace exporter configured (pretty print enabled).")
+		c.config.initLogf("xylium-otel: Stdout trace exporter configured (Pretty print: %t, Custom writer: %t).", c.config.stdoutPrettyPrint(), c.config.StdoutWriter != nil)
 
 	default: // Should not happen if New() validates ExporterType for internal setup.
 		return nil, fmt.Errorf("xylium-otel: unsupported exporter type '%s' for internal TracerProvider setup", c.config.Exporter)
 	}
 
-	// Create OTel Resource
+	res, err := c.buildResource()
+	if err != nil {
+		// Attempt to shutdown the exporter if resource creation fails to prevent leaks.
+		shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 5*time.Second) // Short timeout for exporter shutdown
+		defer cancelShutdown()
+		if cerr := exporter.Shutdown(shutdownCtx); cerr != nil {
+			c.config.AppLogger.Errorf("xylium-otel: Failed to shutdown exporter after resource creation error: %v (Original resource error: %v)", cerr, err)
+		}
+		return nil, err
+	}
+
+	var batchOpts []sdktrace.BatchSpanProcessorOption
+	if c.batchTimeoutSet {
+		batchOpts = append(batchOpts, sdktrace.WithBatchTimeout(c.batchTimeout))
+		c.config.AppLogger.Debugf("xylium-otel: Span batch timeout set to %v (Jitter: up to %v).", c.batchTimeout, c.config.BatchConfig.TimeoutJitter)
+	}
+
+	// Create and return the SDK TracerProvider.
+	c.sampler = newConnectorSampler(c.config.Sampler)
+	c.sampler.pinned = &c.pinnedTraces
+	tpOpts := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(c.sampler), // Use configured sampler (honoring middleware overrides and runtime swaps)
+	}
+	for _, sp := range c.config.SpanProcessors {
+		tpOpts = append(tpOpts, sdktrace.WithSpanProcessor(sp))
+	}
+	if c.config.KeepRecentSpans > 0 {
+		c.recentSpans = newRecentSpansProcessor(c.config.KeepRecentSpans)
+		tpOpts = append(tpOpts, sdktrace.WithSpanProcessor(c.recentSpans))
+		c.config.AppLogger.Debugf("xylium-otel: Retaining the last %d completed spans in memory (see RecentSpans).", c.config.KeepRecentSpans)
+	}
+	// The batcher is registered last so custom processors see spans first.
+	// The exporter is wrapped to honor per-tracer service name overrides (see WithServiceName).
+	// Export outcomes are counted for Connector.Stats, and the exporter can be replaced at runtime (see ReloadExporter).
+	c.stats = &exportStats{}
+	c.reloadableExporter = newReloadableSpanExporter(exporter)
+	exporter = &statsSpanExporter{SpanExporter: c.reloadableExporter, stats: c.stats}
+	var batcher sdktrace.SpanProcessor = sdktrace.NewBatchSpanProcessor(newServiceNameOverrideExporter(exporter), batchOpts...)
+	if c.config.DropSpanPredicate != nil {
+		batcher = newFilteringSpanProcessor(batcher, c.config.DropSpanPredicate, &c.stats.filteredSpans)
+	}
+	tpOpts = append(tpOpts, sdktrace.WithSpanProcessor(batcher))
+	// Externally received spans (see OTLPReceiverHandler) take the same path as local spans after
+	// they end, so they are filtered, batched, and exported serially with the same exporter.
+	// Custom SpanProcessors are skipped since they never saw these spans start.
+	if c.recentSpans != nil {
+		c.receivedSpans = append(c.receivedSpans, c.recentSpans)
+	}
+	c.receivedSpans = append(c.receivedSpans, batcher)
+	if c.seededRand != nil {
+		tpOpts = append(tpOpts, sdktrace.WithIDGenerator(newSeededIDGenerator(c.seededRand)))
+	}
+	if c.config.SpanLimits.isSet() {
+		limits := c.config.SpanLimits.toSDK()
+		tpOpts = append(tpOpts, sdktrace.WithRawSpanLimits(limits))
+		c.config.AppLogger.Debugf("xylium-otel: Span limits configured (Attributes: %d, Events: %d, Links: %d, AttributeValueLength: %d).", limits.AttributeCountLimit, limits.EventCountLimit, limits.LinkCountLimit, limits.AttributeValueLengthLimit)
+	}
+	tp := sdktrace.NewTracerProvider(tpOpts...)
+	return tp, nil
+}
+
+// newOTLPTraceExporter creates an OTLP gRPC span exporter for the given OTLP configuration.
+// It is used for the initial exporter of an internal TracerProvider and by ReloadExporter.
+func (c *Connector) newOTLPTraceExporter(otlp OTLPConfig) (sdktrace.SpanExporter, error) {
+	if otlp.Endpoint == "" {
+		return nil, ErrMissingOTLPEndpoint
+	}
+	opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(otlp.Endpoint)}
+	if otlp.Insecure {
+		opts = append(opts, otlptracegrpc.WithInsecure())
+	}
+	if len(otlp.Headers) > 0 {
+		opts = append(opts, otlptracegrpc.WithHeaders(otlp.headers()))
+	}
+	if otlp.Timeout > 0 {
+		opts = append(opts, otlptracegrpc.WithTimeout(otlp.Timeout))
+	}
+	if otlp.RetryConfig != nil {
+		retry := otlp.RetryConfig.withDefaults()
+		opts = append(opts, otlptracegrpc.WithRetry(otlptracegrpc.RetryConfig(retry)))
+	}
+	if otlp.Compression == "gzip" {
+		opts = append(opts, otlptracegrpc.WithCompressor(otlp.Compression))
+	}
+	for _, dialOption := range otlp.grpcDialOptions() {
+		opts = append(opts, otlptracegrpc.WithDialOption(dialOption))
+	}
+
+	// Create context for exporter creation, can be short-lived.
+	exporterCtx, cancel := context.WithTimeout(context.Background(), otlp.Timeout) // Use configured timeout or a default
+	defer cancel()
+
+	exporter, err := otlptracegrpc.New(exporterCtx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("xylium-otel: creating OTLP gRPC exporter to '%s': %w", otlp.Endpoint, err)
+	}
+	c.config.initLogf("xylium-otel: OTLP gRPC exporter configured for endpoint: %s (Insecure: %t, Timeout: %v, Compression: '%s').", otlp.Endpoint, otlp.Insecure, otlp.Timeout, otlp.Compression)
+	return exporter, nil
+}
+
+// buildResource returns the OTel Resource describing this service, built from the connector's
+// configuration (ServiceName, ServiceVersion, Environment, BuildInfo) and merged with the SDK's default resource.
+// It is computed once and the same instance is shared by all internally managed providers (traces,
+// logs, and any future signal), so their telemetry carries identical service identity.
+// It is only called during New, so no synchronization is needed.
+func (c *Connector) buildResource() (*resource.Resource, error) {
+	if c.resource != nil {
+		return c.resource, nil
+	}
 	resAttrs := []attribute.KeyValue{
 		semconv.ServiceNameKey.String(c.config.ServiceName),
+		attribute.String("otel.library.version", Version), // Version of this connector's instrumentation
 	}
 	if c.config.ServiceVersion != "" {
 		resAttrs = append(resAttrs, semconv.ServiceVersionKey.String(c.config.ServiceVersion))
@@ -304,6 +940,9 @@ func (c *Connector) initInternalTracerProvider() (*sdktrace.TracerProvider, erro
 	if c.config.Environment != "" {
 		resAttrs = append(resAttrs, semconv.DeploymentEnvironmentKey.String(c.config.Environment))
 	}
+	for _, key := range slices.Sorted(maps.Keys(c.config.BuildInfo)) {
+		resAttrs = append(resAttrs, attribute.String("service.build."+key, c.config.BuildInfo[key]))
+	}
 
 	// Merge with default resource (e.g., for host, OS attributes).
 	res, err := resource.Merge(
@@ -311,28 +950,25 @@ func (c *Connector) initInternalTracerProvider() (*sdktrace.TracerProvider, erro
 		resource.NewWithAttributes(semconv.SchemaURL, resAttrs...),
 	)
 	if err != nil {
-		// Attempt to shutdown the exporter if resource creation fails to prevent leaks.
-		shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 5*time.Second) // Short timeout for exporter shutdown
-		defer cancelShutdown()
-		if cerr := exporter.Shutdown(shutdownCtx); cerr != nil {
-			c.config.AppLogger.Errorf("xylium-otel: Failed to shutdown exporter after resource creation error: %v (Original resource error: %v)", cerr, err)
-		}
 		return nil, fmt.Errorf("xylium-otel: merging OTel resources: %w", err)
 	}
+	c.resource = res
+	return res, nil
+}
 
-	// Create and return the SDK TracerProvider.
-	tp := sdktrace.NewTracerProvider(
-		sdktrace.WithBatcher(exporter),
-		sdktrace.WithResource(res),
-		sdktrace.WithSampler(c.config.Sampler), // Use configured sampler
-	)
-	return tp, nil
+// Resource returns the OTel Resource shared by the connector's internally managed providers,
+// e.g. to pass to a MeterProvider created by the application (via sdkmetric.WithResource) so that
+// metrics carry the same service identity as traces and logs.
+// Returns nil if the connector manages no provider (NoOp or external providers only).
+func (c *Connector) Resource() *resource.Resource {
+	return c.resource
 }
 
 // GetTracer returns a trace.Tracer instance.
 // If ManageGlobalProviders is false and an internal TracerProvider was initialized,
 // it returns a tracer from that internal provider. Otherwise, it returns a tracer
-// from the (potentially globally set) OTel TracerProvider.
+// from the (potentially globally set) OTel TracerProvider. If the global provider was chosen via
+// Config.UseGlobalProvider, tracers always come from otel.GetTracerProvider().
 // `instrumentationName` is the name of the library or component creating spans.
 // `opts` are optional `trace.TracerOption`s.
 func (c *Connector) GetTracer(instrumentationName string, opts ...trace.TracerOption) trace.Tracer {
@@ -341,6 +977,11 @@ func (c *Connector) GetTracer(instrumentationName string, opts ...trace.TracerOp
 		return otel.GetTracerProvider().Tracer(instrumentationName, opts...)
 	}
 
+	if c.config.UseGlobalProvider && c.config.ExternalSDKTracerProvider == nil && c.config.ExternalTracerProvider == nil {
+		// The global provider was chosen explicitly, regardless of ManageGlobalProviders.
+		return otel.GetTracerProvider().Tracer(instrumentationName, opts...)
+	}
+
 	if c.config.ManageGlobalProviders != nil && !*c.config.ManageGlobalProviders {
 		// If not managing globals, and we have an internal SDK provider, use it.
 		if c.tracerProvider != nil {
@@ -365,12 +1006,47 @@ func (c *Connector) GetTracer(instrumentationName string, opts ...trace.TracerOp
 	return otel.Tracer(instrumentationName, opts...)
 }
 
+// Tracer returns the connector's own pre-built tracer (instrumentation name "xylium-otel-connector"),
+// avoiding re-resolving a tracer from the provider on every call.
+// If the connector IsNoOp, a no-op tracer is returned.
+func (c *Connector) Tracer() trace.Tracer {
+	if c.isNoOp || c.tracer == nil {
+		return tracenoop.NewTracerProvider().Tracer("xylium-otel-connector")
+	}
+	return c.tracer
+}
+
+// TracerProvider returns the TracerProvider used by the connector: the internally managed
+// *sdktrace.TracerProvider, the external provider supplied in Config, or the global provider
+// if Config.UseGlobalProvider is set.
+// Advanced users can type-assert it to *sdktrace.TracerProvider to call RegisterSpanProcessor
+// (e.g., to add a tail-sampling processor). Mutating the provider is at the caller's own risk;
+// the connector still owns the lifecycle of an internally managed provider.
+// Returns nil if the connector IsNoOp.
+func (c *Connector) TracerProvider() trace.TracerProvider {
+	if c.isNoOp {
+		return nil
+	}
+	switch {
+	case c.tracerProvider != nil:
+		return c.tracerProvider
+	case c.config.ExternalSDKTracerProvider != nil:
+		return c.config.ExternalSDKTracerProvider
+	case c.config.ExternalTracerProvider != nil:
+		return c.config.ExternalTracerProvider
+	case c.config.UseGlobalProvider:
+		return otel.GetTracerProvider()
+	}
+	return nil
+}
+
 // Propagator returns the configured TextMapPropagator.
 // If ManageGlobalProviders is false, it returns the propagator instance held by the connector.
 // Otherwise, it returns the global OTel propagator.
+// A propagator is available even in NoOp mode (e.g., Exporter 'none'), unless Config.Disabled is true.
 func (c *Connector) Propagator() propagation.TextMapPropagator {
-	if c.isNoOp {
-		return propagation.NewCompositeTextMapPropagator() // Return a NoOp-safe default
+	if c.isNoOp && c.propagator == nil {
+		return propagation.NewCompositeTextMapPropagator() // Return a NoOp-safe default (connector fully disabled)
 	}
 
 	if c.config.ManageGlobalProviders != nil && !*c.config.ManageGlobalProviders {
@@ -388,44 +1064,135 @@ func (c *Connector) Propagator() propagation.TextMapPropagator {
 	return otel.GetTextMapPropagator()
 }
 
-// Close shuts down the internally managed TracerProvider, if one was created by this connector.
-// It respects the Config.ShutdownTimeout. If an external TracerProvider was used,
-// this method is a no-op for the provider's lifecycle.
+// drainPollInterval is how often Drain checks whether in-flight server spans have ended.
+const drainPollInterval = 10 * time.Millisecond
+
+// Drain prepares the connector for shutdown without losing spans of in-flight requests. It waits
+// until every server span started by the connector's middleware has ended (or ctx is done), then
+// force-flushes the internally managed TracerProvider and LoggerProvider so buffered telemetry is
+// exported. Call it after the HTTP server has stopped accepting new requests (e.g., on SIGTERM)
+// and before Close:
+//
+//	drainCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+//	defer cancel()
+//	if err := otelConnector.Drain(drainCtx); err != nil {
+//		logger.Warnf("OTel drain incomplete: %v", err)
+//	}
+//	otelConnector.Close()
+//
+// If ctx is done before all server spans have ended, the flush is still attempted and an error
+// wrapping ctx.Err() is returned. Spans started while Drain is waiting extend the wait.
+func (c *Connector) Drain(ctx context.Context) error {
+	var errs []error
+	if c.activeServerSpans.Load() > 0 {
+		ticker := time.NewTicker(drainPollInterval)
+		defer ticker.Stop()
+	wait:
+		for c.activeServerSpans.Load() > 0 {
+			select {
+			case <-ctx.Done():
+				errs = append(errs, fmt.Errorf("xylium-otel: draining with %d server span(s) still active: %w", c.activeServerSpans.Load(), ctx.Err()))
+				break wait
+			case <-ticker.C:
+			}
+		}
+	}
+
+	// Flush even if waiting timed out, so spans that did end are not lost. The flush gets its own
+	// deadline if ctx is already done.
+	flushCtx := ctx
+	if ctx.Err() != nil {
+		var cancel context.CancelFunc
+		flushCtx, cancel = context.WithTimeout(context.Background(), c.config.ShutdownTimeout)
+		defer cancel()
+	}
+	if c.tracerProvider != nil {
+		if err := c.tracerProvider.ForceFlush(flushCtx); err != nil {
+			errs = append(errs, fmt.Errorf("xylium-otel: flushing managed TracerProvider: %w", err))
+		}
+	}
+	if c.loggerProvider != nil {
+		if err := c.loggerProvider.ForceFlush(flushCtx); err != nil {
+			errs = append(errs, fmt.Errorf("xylium-otel: flushing managed LoggerProvider: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close shuts down the internally managed TracerProvider and LoggerProvider, if they were created
+// by this connector, flushing any pending telemetry. It respects the Config.ShutdownTimeout.
+// If an external TracerProvider was used, this method is a no-op for the provider's lifecycle.
 // Implements io.Closer, allowing Xylium to manage its lifecycle during graceful shutdown
 // when the connector instance is stored using `app.AppSet()`.
+//
+// Close is idempotent and safe for concurrent use, so Xylium's graceful shutdown and an explicit
+// `defer otelConnector.Close()` can both fire: only the first call shuts the providers down and
+// returns its result; concurrent calls wait for it to finish, and later calls return nil.
 func (c *Connector) Close() error {
-	if c.isNoOp {
+	var err error
+	first := false
+	c.closeOnce.Do(func() {
+		first = true
+		err = c.shutdown()
+	})
+	if !first && c.config.AppLogger != nil {
+		c.config.AppLogger.Debug("xylium-otel: Close() called again; the connector was already closed.")
+	}
+	return err
+}
+
+// shutdown performs the actual shutdown for Close, which guarantees it runs at most once.
+func (c *Connector) shutdown() error {
+	c.reloadMu.Lock()
+	c.closed = true // Waits for an in-flight ReloadExporter, so its exporter is shut down below.
+	c.reloadMu.Unlock()
+
+	if c.tracerProvider == nil && c.loggerProvider == nil {
 		if c.config.AppLogger != nil { // Check logger existence before using
-			c.config.AppLogger.Debug("xylium-otel: Close() called on a NoOp connector. Nothing to shut down.")
+			if c.isNoOp {
+				c.config.AppLogger.Debug("xylium-otel: Close() called on a NoOp connector. Nothing to shut down.")
+			} else {
+				c.config.AppLogger.Info("xylium-otel: Close() called, but TracerProvider was externally managed or not initialized by this connector. No internal shutdown performed.")
+			}
 		}
 		return nil
 	}
 
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), c.config.ShutdownTimeout)
+	defer cancel()
+
+	var errs []error
 	// Only shutdown the tracerProvider if it was internally created and managed by this connector.
 	// c.tracerProvider (the *sdktrace.TracerProvider) is only non-nil if created internally.
 	if c.tracerProvider != nil {
 		if c.config.AppLogger != nil {
 			c.config.AppLogger.Infof("xylium-otel: Shutting down internally managed OpenTelemetry TracerProvider (Timeout: %v)...", c.config.ShutdownTimeout)
 		}
-		shutdownCtx, cancel := context.WithTimeout(context.Background(), c.config.ShutdownTimeout)
-		defer cancel()
-
 		if err := c.tracerProvider.Shutdown(shutdownCtx); err != nil {
 			if c.config.AppLogger != nil {
 				c.config.AppLogger.Errorf("xylium-otel: Error shutting down managed TracerProvider: %v", err)
 			}
-			return fmt.Errorf("xylium-otel: shutting down managed TracerProvider: %w", err)
-		}
-		if c.config.AppLogger != nil {
+			errs = append(errs, fmt.Errorf("xylium-otel: shutting down managed TracerProvider: %w", err))
+		} else if c.config.AppLogger != nil {
 			c.config.AppLogger.Info("xylium-otel: Internally managed TracerProvider shut down successfully.")
 		}
-		return nil
 	}
 
-	if c.config.AppLogger != nil {
-		c.config.AppLogger.Info("xylium-otel: Close() called, but TracerProvider was externally managed or not initialized by this connector. No internal shutdown performed.")
+	if c.loggerProvider != nil {
+		if c.config.AppLogger != nil {
+			c.config.AppLogger.Infof("xylium-otel: Shutting down internally managed OpenTelemetry LoggerProvider (Timeout: %v)...", c.config.ShutdownTimeout)
+		}
+		if err := c.loggerProvider.Shutdown(shutdownCtx); err != nil {
+			if c.config.AppLogger != nil {
+				c.config.AppLogger.Errorf("xylium-otel: Error shutting down managed LoggerProvider: %v", err)
+			}
+			errs = append(errs, fmt.Errorf("xylium-otel: shutting down managed LoggerProvider: %w", err))
+		} else if c.config.AppLogger != nil {
+			c.config.AppLogger.Info("xylium-otel: Internally managed LoggerProvider shut down successfully.")
+		}
 	}
-	return nil
+
+	return errors.Join(errs...)
 }
 
 // IsNoOp returns true if the connector is configured to be a no-operation instance
@@ -435,5 +1202,76 @@ func (c *Connector) IsNoOp() bool {
 	return c.isNoOp
 }
 
+// ConfigSummary returns a snapshot of the connector's resolved (post-default) configuration,
+// suitable for exposing on an admin or debug endpoint to make misconfiguration obvious.
+// The returned map contains:
+//   - "service_name": the configured service name.
+//   - "noop": whether the connector is a NoOp instance.
+//   - "exporter": the resolved trace exporter type (only meaningful for an internal TracerProvider).
+//   - "tracer_provider": "internal", "external_sdk", "external", "global", or "none".
+//   - "sampler": the description of the active sampler (reflecting runtime SetSampler calls).
+//   - "propagators": the names of the active propagators (e.g., "tracecontext", "baggage"),
+//     resolved when the connector was created. Unknown propagators are reported by Go type name.
+//   - "manage_global_providers": whether the connector registers itself as the global OTel provider.
+//   - "logs_enabled" and "logs_exporter": the log export configuration.
+//
+// Secrets such as OTLP headers are intentionally not included.
+func (c *Connector) ConfigSummary() map[string]any {
+	tracerProviderSource := "none"
+	switch {
+	case c.tracerProvider != nil:
+		tracerProviderSource = "internal"
+	case c.config.ExternalSDKTracerProvider != nil:
+		tracerProviderSource = "external_sdk"
+	case c.config.ExternalTracerProvider != nil:
+		tracerProviderSource = "external"
+	case c.config.UseGlobalProvider:
+		tracerProviderSource = "global"
+	}
+
+	samplerDescription := ""
+	if c.sampler != nil {
+		samplerDescription = c.sampler.Description()
+	} else if c.config.Sampler != nil {
+		samplerDescription = c.config.Sampler.Description()
+	}
+
+	return map[string]any{
+		"service_name":            c.config.ServiceName,
+		"noop":                    c.isNoOp,
+		"exporter":                string(c.config.Exporter),
+		"tracer_provider":         tracerProviderSource,
+		"sampler":                 samplerDescription,
+		"propagators":             slices.Clone(c.propagatorNames),
+		"manage_global_providers": c.config.ManageGlobalProviders != nil && *c.config.ManageGlobalProviders,
+		"logs_enabled":            c.config.Logs.Enabled,
+		"logs_exporter":           string(c.config.Logs.Exporter),
+	}
+}
+
+// propagatorNames returns the names of the propagators making up `p`, using the names accepted by
+// OTEL_PROPAGATORS for the well-known ones. Composite propagators are flattened into their members.
+func propagatorNames(p propagation.TextMapPropagator) []string {
+	switch p.(type) {
+	case nil:
+		return []string{}
+	case propagation.TraceContext:
+		return []string{"tracecontext"}
+	case propagation.Baggage:
+		return []string{"baggage"}
+	}
+	// propagation.NewCompositeTextMapPropagator returns an unexported slice of its members.
+	if v := reflect.ValueOf(p); v.Kind() == reflect.Slice {
+		names := []string{}
+		for i := 0; i < v.Len(); i++ {
+			if member, ok := v.Index(i).Interface().(propagation.TextMapPropagator); ok {
+				names = append(names, propagatorNames(member)...)
+			}
+		}
+		return names
+	}
+	return []string{fmt.Sprintf("%T", p)}
+}
+
 // Ensure Connector implements io.Closer for Xylium's graceful shutdown.
 var _ io.Closer = (*Connector)(nil)
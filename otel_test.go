@@ -0,0 +1,405 @@
+package xyliumotel
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/arwahdevops/xylium-core/src/xylium"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/log"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// discardLogger returns a Xylium logger that drops everything below Error.
+func discardLogger() xylium.Logger {
+	return xylium.NewDefaultLoggerWithConfig(xylium.LoggerConfig{Level: xylium.LevelError, Output: io.Discard})
+}
+
+func TestResourceSharedByTracesAndLogs(t *testing.T) {
+	var logOutput bytes.Buffer
+	recorder := tracetest.NewSpanRecorder()
+	manageGlobal, prettyPrint := false, false
+	connector, err := New(Config{
+		ServiceName:           "resource-test",
+		ServiceVersion:        "1.2.3",
+		AppLogger:             discardLogger(),
+		Exporter:              ExporterStdout,
+		StdoutWriter:          &logOutput,
+		StdoutPrettyPrint:     &prettyPrint,
+		ManageGlobalProviders: &manageGlobal,
+		SpanProcessors:        []sdktrace.SpanProcessor{recorder},
+		Logs:                  LogsConfig{Enabled: true},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	_, span := connector.GetTracer("resource-test").Start(context.Background(), "operation")
+	span.End()
+	var record log.Record
+	record.SetBody(log.StringValue("message"))
+	connector.GetOtelLogger("resource-test").Emit(context.Background(), record)
+	if err := connector.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	want := attributesByKey(connector.Resource().Attributes())
+	if len(want) == 0 {
+		t.Fatal("Connector.Resource() has no attributes")
+	}
+
+	ended := recorder.Ended()
+	if len(ended) != 1 {
+		t.Fatalf("recorded %d spans, want 1", len(ended))
+	}
+	if got := attributesByKey(ended[0].Resource().Attributes()); !maps.Equal(got, want) {
+		t.Errorf("span resource = %v, want %v", got, want)
+	}
+
+	gotLogs, err := logRecordResource(&logOutput)
+	if err != nil {
+		t.Fatalf("decoding stdout log record: %v", err)
+	}
+	if !maps.Equal(gotLogs, want) {
+		t.Errorf("log record resource = %v, want %v", gotLogs, want)
+	}
+}
+
+// attributesByKey renders attributes as a key to value map for comparison.
+func attributesByKey(attrs []attribute.KeyValue) map[string]string {
+	out := make(map[string]string, len(attrs))
+	for _, kv := range attrs {
+		out[string(kv.Key)] = kv.Value.Emit()
+	}
+	return out
+}
+
+// logRecordResource extracts the resource attributes of the log record written by the stdout
+// log exporter, ignoring the span records the stdout trace exporter writes to the same buffer.
+func logRecordResource(r io.Reader) (map[string]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var record struct {
+			Body     json.RawMessage
+			Resource []struct {
+				Key   string
+				Value struct {
+					Value any
+				}
+			}
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, err
+		}
+		if record.Body == nil {
+			continue // A span record
+		}
+		out := make(map[string]string, len(record.Resource))
+		for _, kv := range record.Resource {
+			out[kv.Key] = fmt.Sprint(kv.Value.Value)
+		}
+		return out, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("no log record found")
+}
+
+func TestCloseConcurrent(t *testing.T) {
+	connector, _ := NewTestConnector()
+
+	const callers = 8
+	var wg sync.WaitGroup
+	errs := make(chan error, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- connector.Close()
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("Close() error = %v", err)
+		}
+	}
+
+	if err := connector.Close(); err != nil {
+		t.Errorf("Close() after concurrent calls error = %v", err)
+	}
+}
+
+func TestSpanLimitsTruncateAttributeValues(t *testing.T) {
+	connector, recorder := NewTestConnector(Config{
+		SpanLimits: SpanLimitsConfig{AttributeValueLengthLimit: 4, MaxAttributesPerSpan: 2},
+	})
+	defer connector.Close()
+
+	_, span := connector.GetTracer("limits-test").Start(context.Background(), "operation")
+	span.SetAttributes(
+		attribute.String("long", "abcdefgh"),
+		attribute.String("short", "ab"),
+		attribute.String("dropped", "value"),
+	)
+	span.End()
+
+	ended := recorder.Ended()
+	if len(ended) != 1 {
+		t.Fatalf("recorded %d spans, want 1", len(ended))
+	}
+	got := attributesByKey(ended[0].Attributes())
+	want := map[string]string{"long": "abcd", "short": "ab"}
+	if !maps.Equal(got, want) {
+		t.Errorf("attributes = %v, want %v", got, want)
+	}
+	if dropped := ended[0].DroppedAttributes(); dropped != 1 {
+		t.Errorf("DroppedAttributes() = %d, want 1", dropped)
+	}
+}
+
+func TestStdoutExporterRoundTripsResourceAndScope(t *testing.T) {
+	var output bytes.Buffer
+	manageGlobal, prettyPrint := false, false
+	connector, err := New(Config{
+		ServiceName:           "stdout-test",
+		AppLogger:             discardLogger(),
+		Exporter:              ExporterStdout,
+		StdoutWriter:          &output,
+		StdoutPrettyPrint:     &prettyPrint,
+		ManageGlobalProviders: &manageGlobal,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	tracer := connector.GetTracer("stdout-scope", trace.WithInstrumentationVersion("0.9.0"))
+	_, span := tracer.Start(context.Background(), "operation")
+	span.End()
+	if err := connector.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	var exported struct {
+		Name     string
+		Resource []struct {
+			Key   string
+			Value struct {
+				Value any
+			}
+		}
+		InstrumentationScope struct {
+			Name    string
+			Version string
+		}
+	}
+	if err := json.NewDecoder(&output).Decode(&exported); err != nil {
+		t.Fatalf("decoding stdout span: %v", err)
+	}
+	if exported.Name != "operation" {
+		t.Errorf("span name = %q, want %q", exported.Name, "operation")
+	}
+	resourceAttrs := make(map[string]string, len(exported.Resource))
+	for _, kv := range exported.Resource {
+		resourceAttrs[kv.Key] = fmt.Sprint(kv.Value.Value)
+	}
+	if got := resourceAttrs["service.name"]; got != "stdout-test" {
+		t.Errorf("resource service.name = %q, want %q (resource: %v)", got, "stdout-test", resourceAttrs)
+	}
+	if got := exported.InstrumentationScope; got.Name != "stdout-scope" || got.Version != "0.9.0" {
+		t.Errorf("instrumentation scope = %+v, want stdout-scope 0.9.0", got)
+	}
+}
+
+func TestTracerProviderPrecedence(t *testing.T) {
+	previousGlobal := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(previousGlobal) })
+
+	globalProvider := sdktrace.NewTracerProvider()
+	otel.SetTracerProvider(globalProvider)
+	externalSDK := sdktrace.NewTracerProvider()
+	external := sdktrace.NewTracerProvider()
+	t.Cleanup(func() {
+		_ = globalProvider.Shutdown(context.Background())
+		_ = externalSDK.Shutdown(context.Background())
+		_ = external.Shutdown(context.Background())
+	})
+
+	tests := []struct {
+		name         string
+		cfg          Config
+		want         trace.TracerProvider // nil for a NoOp connector
+		wantInternal bool                 // Expect the connector's own SDK provider instead of want
+	}{
+		{
+			name: "external SDK wins over everything",
+			cfg:  Config{ExternalSDKTracerProvider: externalSDK, ExternalTracerProvider: external, UseGlobalProvider: true, Exporter: ExporterStdout},
+			want: externalSDK,
+		},
+		{
+			name: "external wins over global and exporter",
+			cfg:  Config{ExternalTracerProvider: external, UseGlobalProvider: true, Exporter: ExporterStdout},
+			want: external,
+		},
+		{
+			name: "global wins over exporter",
+			cfg:  Config{UseGlobalProvider: true, Exporter: ExporterStdout},
+			want: globalProvider,
+		},
+		{
+			name: "global with exporter none",
+			cfg:  Config{UseGlobalProvider: true, Exporter: ExporterNone},
+			want: globalProvider,
+		},
+		{
+			name:         "exporter builds an internal provider",
+			cfg:          Config{Exporter: ExporterStdout},
+			wantInternal: true,
+		},
+		{
+			name: "exporter none without global is NoOp",
+			cfg:  Config{Exporter: ExporterNone},
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manageGlobal := false
+			cfg := tt.cfg
+			cfg.ServiceName = "precedence-test"
+			cfg.AppLogger = discardLogger()
+			cfg.StdoutWriter = io.Discard
+			cfg.ManageGlobalProviders = &manageGlobal
+			connector, err := New(cfg)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			defer connector.Close()
+
+			got := connector.TracerProvider()
+			want := tt.want
+			if tt.wantInternal {
+				if connector.tracerProvider == nil {
+					t.Fatal("connector has no internal tracer provider")
+				}
+				want = connector.tracerProvider
+			}
+			if got != want {
+				t.Errorf("TracerProvider() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestCloseDoesNotShutDownGlobalProvider(t *testing.T) {
+	previousGlobal := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(previousGlobal) })
+
+	recorder := tracetest.NewSpanRecorder()
+	globalProvider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	t.Cleanup(func() { _ = globalProvider.Shutdown(context.Background()) })
+	otel.SetTracerProvider(globalProvider)
+
+	manageGlobal := false
+	connector, err := New(Config{
+		ServiceName:           "global-test",
+		AppLogger:             discardLogger(),
+		UseGlobalProvider:     true,
+		ManageGlobalProviders: &manageGlobal,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err := connector.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	_, span := globalProvider.Tracer("global-test").Start(context.Background(), "after-close")
+	span.End()
+	if ended := recorder.Ended(); len(ended) != 1 {
+		t.Errorf("global provider recorded %d spans after Close, want 1 (it must not be shut down)", len(ended))
+	}
+}
+
+func TestConfigSummaryPropagators(t *testing.T) {
+	tests := []struct {
+		name       string
+		propagator propagation.TextMapPropagator
+		want       []string
+	}{
+		{name: "default", want: []string{"tracecontext", "baggage"}},
+		{name: "single", propagator: propagation.TraceContext{}, want: []string{"tracecontext"}},
+		{
+			name:       "nested composite",
+			propagator: propagation.NewCompositeTextMapPropagator(propagation.Baggage{}, propagation.NewCompositeTextMapPropagator(propagation.TraceContext{})),
+			want:       []string{"baggage", "tracecontext"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			connector, _ := NewTestConnector(Config{Propagator: tt.propagator})
+			defer connector.Close()
+			got, _ := connector.ConfigSummary()["propagators"].([]string)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf(`ConfigSummary()["propagators"] = %v, want %v`, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFailOpen(t *testing.T) {
+	t.Setenv(envOTLPEndpoint, "")
+	t.Setenv(envOTLPTracesEndpoint, "")
+	t.Setenv(envOTLPLogsEndpoint, "")
+
+	newFailOpen := func(cfg Config) (*Connector, error) {
+		manageGlobal := false
+		cfg.ServiceName = "fail-open-test"
+		cfg.AppLogger = discardLogger()
+		cfg.ManageGlobalProviders = &manageGlobal
+		cfg.FailOpen = true
+		return New(cfg)
+	}
+
+	t.Run("config errors are returned", func(t *testing.T) {
+		for _, cfg := range []Config{
+			{Exporter: ExporterOTLPGRPC},
+			{Exporter: ExporterStdout, StdoutWriter: io.Discard, Logs: LogsConfig{Enabled: true, Exporter: ExporterOTLPGRPC}},
+		} {
+			if _, err := newFailOpen(cfg); !errors.Is(err, ErrMissingOTLPEndpoint) {
+				t.Errorf("New(%+v) error = %v, want ErrMissingOTLPEndpoint", cfg, err)
+			}
+		}
+		if _, err := newFailOpen(Config{Exporter: "zipkin"}); err == nil {
+			t.Error("New() with an unsupported exporter succeeded, want an error")
+		}
+	})
+
+	t.Run("exporter creation failures fail open", func(t *testing.T) {
+		// The endpoint passes validation but the gRPC client rejects it as a target.
+		connector, err := newFailOpen(Config{Exporter: ExporterOTLPGRPC, OTLP: OTLPConfig{Endpoint: "dns:///%%", Insecure: true}})
+		if err != nil {
+			t.Fatalf("New() error = %v, want a NoOp connector", err)
+		}
+		defer connector.Close()
+		if !connector.IsNoOp() {
+			t.Error("IsNoOp() = false, want true after failing open")
+		}
+	})
+}
@@ -0,0 +1,143 @@
+// Package xyliumotel provides the OpenTelemetry connector for the Xylium framework.
+// This file contains diagnostics for validating the export path to an OTLP collector.
+package xyliumotel
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"time"
+
+	collectortracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+)
+
+// probeSpanName is the name of the single span sent by ProbeCollector.
+const probeSpanName = "xylium-otel.collector-probe"
+
+// CollectorInfo describes the outcome of a ProbeCollector call.
+type CollectorInfo struct {
+	// Endpoint is the collector endpoint that was probed.
+	Endpoint string
+	// Protocol is the OTLP transport protocol used to reach the collector (e.g., "grpc").
+	Protocol string
+	// Insecure reports whether the probe used a plaintext (non-TLS) connection.
+	Insecure bool
+	// Accepted is true if the collector accepted the probe span without rejecting it.
+	Accepted bool
+	// RejectedSpans is the number of spans the collector reported as rejected in a partial success response.
+	RejectedSpans int64
+	// PartialSuccessMessage holds the warning or error message from a partial success response, if any.
+	PartialSuccessMessage string
+	// Latency is the round-trip time of the probe export call.
+	Latency time.Duration
+}
+
+// ProbeCollector validates the full OTLP export path end-to-end by sending a single
+// minimal probe span (named "xylium-otel.collector-probe") directly to the configured collector,
+// using the same endpoint, TLS mode, and headers as the managed exporter.
+// It reports whether the collector accepted the data and surfaces any partial-success warnings.
+//
+// This is intended as a diagnostic (e.g., from a CLI command or an admin endpoint) before going live.
+// It is only supported when Config.Exporter is ExporterOTLPGRPC. If ctx has no deadline,
+// Config.OTLP.Timeout is applied.
+func (c *Connector) ProbeCollector(ctx context.Context) (*CollectorInfo, error) {
+	if c.isNoOp {
+		return nil, errors.New("xylium-otel: ProbeCollector called on a NoOp connector")
+	}
+	if c.config.Exporter != ExporterOTLPGRPC {
+		return nil, fmt.Errorf("xylium-otel: ProbeCollector is only supported for the '%s' exporter (configured: '%s')", ExporterOTLPGRPC, c.config.Exporter)
+	}
+	otlp := c.currentOTLPConfig() // Snapshot, since ReloadExporter may replace it concurrently
+	if otlp.Endpoint == "" {
+		return nil, ErrMissingOTLPEndpoint
+	}
+
+	if _, hasDeadline := ctx.Deadline(); !hasDeadline {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, otlp.Timeout)
+		defer cancel()
+	}
+
+	info := &CollectorInfo{
+		Endpoint: otlp.Endpoint,
+		Protocol: "grpc",
+		Insecure: otlp.Insecure,
+	}
+
+	creds := credentials.NewTLS(&tls.Config{})
+	if otlp.Insecure {
+		creds = insecure.NewCredentials()
+	}
+	dialOptions := append([]grpc.DialOption{grpc.WithTransportCredentials(creds)}, otlp.grpcDialOptions()...)
+	conn, err := grpc.NewClient(otlp.Endpoint, dialOptions...)
+	if err != nil {
+		return info, fmt.Errorf("xylium-otel: creating gRPC client for collector probe to '%s': %w", otlp.Endpoint, err)
+	}
+	defer conn.Close()
+
+	if len(otlp.Headers) > 0 {
+		ctx = metadata.NewOutgoingContext(ctx, metadata.New(otlp.headers()))
+	}
+
+	req, err := c.newProbeRequest()
+	if err != nil {
+		return info, err
+	}
+
+	start := time.Now()
+	resp, err := collectortracepb.NewTraceServiceClient(conn).Export(ctx, req)
+	info.Latency = time.Since(start)
+	if err != nil {
+		c.config.AppLogger.Warnf("xylium-otel: Collector probe to '%s' failed after %v: %v", info.Endpoint, info.Latency, err)
+		return info, fmt.Errorf("xylium-otel: exporting probe span to '%s': %w", otlp.Endpoint, err)
+	}
+
+	if ps := resp.GetPartialSuccess(); ps != nil {
+		info.RejectedSpans = ps.GetRejectedSpans()
+		info.PartialSuccessMessage = ps.GetErrorMessage()
+	}
+	info.Accepted = info.RejectedSpans == 0
+	c.config.AppLogger.Infof("xylium-otel: Collector probe to '%s' completed in %v (Accepted: %t, RejectedSpans: %d).", info.Endpoint, info.Latency, info.Accepted, info.RejectedSpans)
+	return info, nil
+}
+
+// newProbeRequest builds a minimal OTLP export request containing a single span
+// attributed to the connector's configured service.
+func (c *Connector) newProbeRequest() (*collectortracepb.ExportTraceServiceRequest, error) {
+	ids := make([]byte, 24) // 16-byte trace ID followed by an 8-byte span ID.
+	if _, err := rand.Read(ids); err != nil {
+		return nil, fmt.Errorf("xylium-otel: generating probe span IDs: %w", err)
+	}
+	now := uint64(time.Now().UnixNano())
+
+	return &collectortracepb.ExportTraceServiceRequest{
+		ResourceSpans: []*tracepb.ResourceSpans{{
+			Resource: &resourcepb.Resource{
+				Attributes: []*commonpb.KeyValue{{
+					Key:   "service.name",
+					Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: c.config.ServiceName}},
+				}},
+			},
+			ScopeSpans: []*tracepb.ScopeSpans{{
+				Scope: &commonpb.InstrumentationScope{Name: "xylium-otel-connector", Version: Version},
+				Spans: []*tracepb.Span{{
+					TraceId:           ids[:16],
+					SpanId:            ids[16:],
+					Name:              probeSpanName,
+					Kind:              tracepb.Span_SPAN_KIND_INTERNAL,
+					StartTimeUnixNano: now,
+					EndTimeUnixNano:   now,
+				}},
+			}},
+		}},
+	}, nil
+}
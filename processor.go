@@ -0,0 +1,52 @@
+// Package xyliumotel provides the OpenTelemetry connector for the Xylium framework.
+// This file contains span processors used by the internally managed TracerProvider.
+package xyliumotel
+
+import (
+	"context"
+	"sync/atomic"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// filteringSpanProcessor wraps another SpanProcessor and withholds completed spans
+// matching a predicate from it (see Config.DropSpanPredicate).
+type filteringSpanProcessor struct {
+	next    sdktrace.SpanProcessor
+	drop    func(s sdktrace.ReadOnlySpan) bool
+	dropped *atomic.Int64 // Incremented for every withheld span (see ConnectorStats.FilteredSpans)
+}
+
+// newFilteringSpanProcessor returns a SpanProcessor that forwards to `next` all spans
+// for which `drop` returns false, counting withheld spans in `dropped`.
+func newFilteringSpanProcessor(next sdktrace.SpanProcessor, drop func(s sdktrace.ReadOnlySpan) bool, dropped *atomic.Int64) *filteringSpanProcessor {
+	return &filteringSpanProcessor{next: next, drop: drop, dropped: dropped}
+}
+
+// OnStart forwards the span start to the wrapped processor.
+// Implements sdktrace.SpanProcessor.
+func (p *filteringSpanProcessor) OnStart(parent context.Context, s sdktrace.ReadWriteSpan) {
+	p.next.OnStart(parent, s)
+}
+
+// OnEnd forwards the completed span to the wrapped processor unless the predicate drops it.
+// Implements sdktrace.SpanProcessor.
+func (p *filteringSpanProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	if p.drop(s) {
+		p.dropped.Add(1)
+		return
+	}
+	p.next.OnEnd(s)
+}
+
+// Shutdown shuts down the wrapped processor.
+// Implements sdktrace.SpanProcessor.
+func (p *filteringSpanProcessor) Shutdown(ctx context.Context) error {
+	return p.next.Shutdown(ctx)
+}
+
+// ForceFlush flushes the wrapped processor.
+// Implements sdktrace.SpanProcessor.
+func (p *filteringSpanProcessor) ForceFlush(ctx context.Context) error {
+	return p.next.ForceFlush(ctx)
+}
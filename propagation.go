@@ -0,0 +1,33 @@
+// Package xyliumotel provides the OpenTelemetry connector for the Xylium framework.
+// This file contains helpers for propagating trace context across process boundaries
+// outside of HTTP requests (e.g., message queues and async workers).
+package xyliumotel
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// defaultCarrierTracerName is the name of the tracer used by the carrier-based span helpers.
+const defaultCarrierTracerName = "xylium.otel.carrier"
+
+// StartSpanFromCarrier extracts trace context from `carrier` (e.g., headers of a queue message)
+// using the connector's Propagator, then starts a span named `name` as a child of the extracted
+// context. This lets async/queue workers continue a trace serialized by the producer.
+// If the carrier holds no trace context, a new root span is started from `ctx`.
+// The Propagator and tracer respect the ManageGlobalProviders setting.
+// Callers must end the returned span.
+func (c *Connector) StartSpanFromCarrier(ctx context.Context, carrier propagation.TextMapCarrier, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	propagatedCtx := c.Propagator().Extract(ctx, carrier)
+	tracer := c.GetTracer(defaultCarrierTracerName, trace.WithInstrumentationVersion(Version))
+	return tracer.Start(propagatedCtx, name, opts...)
+}
+
+// InjectIntoCarrier serializes the trace context (and baggage) from `ctx` into `carrier`
+// (e.g., Kafka or NATS message headers) using the connector's Propagator, so consumers can
+// continue the trace with StartSpanFromCarrier. The Propagator respects the ManageGlobalProviders setting.
+func (c *Connector) InjectIntoCarrier(ctx context.Context, carrier propagation.TextMapCarrier) {
+	c.Propagator().Inject(ctx, carrier)
+}
@@ -0,0 +1,7 @@
+//go:build race
+
+package xyliumotel
+
+// raceEnabled reports whether the test binary was built with -race, whose instrumentation adds
+// allocations that allocation-count tests must not attribute to the code under test.
+const raceEnabled = true
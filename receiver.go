@@ -0,0 +1,354 @@
+// Package xyliumotel provides the OpenTelemetry connector for the Xylium framework.
+// This file contains an OTLP/HTTP trace receiver that forwards spans to the connector's export pipeline.
+package xyliumotel
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/arwahdevops/xylium-core/src/xylium"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	collectortracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// Content types accepted by OTLPReceiverHandler, as defined by the OTLP/HTTP specification.
+const (
+	otlpContentTypeProtobuf = "application/x-protobuf"
+	otlpContentTypeJSON     = "application/json"
+)
+
+// otlpReceiverMaxBodyBytes is the largest request body accepted by OTLPReceiverHandler.
+// Larger payloads are rejected with 413 Request Entity Too Large.
+const otlpReceiverMaxBodyBytes = 4 << 20 // 4 MiB
+
+// OTLPReceiverHandler returns a Xylium handler that accepts OTLP/HTTP trace export requests
+// (e.g., from browser RUM agents) and forwards the received spans to the connector's own exporter,
+// merging them into the same pipeline as server-side spans.
+// Both binary protobuf ("application/x-protobuf") and JSON ("application/json") encodings are supported.
+//
+// Mount it on the standard OTLP path for local full-stack tracing without a collector:
+//
+//	app.POST("/v1/traces", otelConnector.OTLPReceiverHandler())
+//
+// The handler requires an internally managed TracerProvider (i.e., Exporter is not 'none' and
+// no external provider is used); otherwise it responds with 503 Service Unavailable.
+// Received spans are not sampled again. They are handed to the same batch span processor as
+// server-side spans, so they are subject to DropSpanPredicate, retained by KeepRecentSpans, and
+// exported asynchronously; the request does not wait for the export. Custom SpanProcessors do
+// not see them. Bodies larger than 4 MiB are rejected with 413 Request Entity Too Large; to stop
+// oversized bodies from being read at all, also bound the server's MaxRequestBodySize.
+func (c *Connector) OTLPReceiverHandler() xylium.HandlerFunc {
+	return func(ctx *xylium.Context) error {
+		if len(c.receivedSpans) == 0 {
+			return xylium.NewHTTPError(http.StatusServiceUnavailable, "OTLP receiver requires an exporter managed by the xylium-otel connector.")
+		}
+		if ctx.Ctx.Request.Header.ContentLength() > otlpReceiverMaxBodyBytes || len(ctx.Body()) > otlpReceiverMaxBodyBytes {
+			return xylium.NewHTTPError(http.StatusRequestEntityTooLarge, fmt.Sprintf("OTLP payload exceeds %d bytes.", otlpReceiverMaxBodyBytes))
+		}
+
+		contentType := ctx.ContentType()
+		if i := strings.IndexByte(contentType, ';'); i >= 0 {
+			contentType = contentType[:i]
+		}
+		contentType = strings.TrimSpace(strings.ToLower(contentType))
+
+		req := &collectortracepb.ExportTraceServiceRequest{}
+		switch contentType {
+		case otlpContentTypeProtobuf:
+			if err := proto.Unmarshal(ctx.Body(), req); err != nil {
+				return xylium.NewHTTPError(http.StatusBadRequest, "Invalid OTLP protobuf payload.").WithInternal(err)
+			}
+		case otlpContentTypeJSON:
+			if err := unmarshalOTLPJSON(ctx.Body(), req); err != nil {
+				return xylium.NewHTTPError(http.StatusBadRequest, "Invalid OTLP JSON payload.").WithInternal(err)
+			}
+		default:
+			return xylium.NewHTTPError(http.StatusUnsupportedMediaType, fmt.Sprintf("Unsupported OTLP content type '%s'.", contentType))
+		}
+
+		spans, err := otlpToReadOnlySpans(req)
+		if err != nil {
+			return xylium.NewHTTPError(http.StatusBadRequest, "Invalid OTLP trace data.").WithInternal(err)
+		}
+		if len(spans) > 0 {
+			for _, span := range spans {
+				for _, processor := range c.receivedSpans {
+					processor.OnEnd(span)
+				}
+			}
+			c.config.AppLogger.Debugf("xylium-otel: OTLP receiver queued %d span(s) for export.", len(spans))
+		}
+
+		resp := &collectortracepb.ExportTraceServiceResponse{}
+		var body []byte
+		if contentType == otlpContentTypeJSON {
+			body, err = protojson.Marshal(resp)
+		} else {
+			body, err = proto.Marshal(resp)
+		}
+		if err != nil {
+			return fmt.Errorf("xylium-otel: encoding OTLP receiver response: %w", err)
+		}
+		ctx.Status(http.StatusOK).SetContentType(contentType)
+		return ctx.Write(body)
+	}
+}
+
+// unmarshalOTLPJSON decodes an OTLP/JSON trace request. OTLP/JSON encodes trace and span IDs
+// as hex strings rather than the base64 used by standard protobuf JSON mapping, so the IDs
+// are re-encoded before decoding with protojson.
+func unmarshalOTLPJSON(data []byte, req *collectortracepb.ExportTraceServiceRequest) error {
+	var tree any
+	if err := json.Unmarshal(data, &tree); err != nil {
+		return err
+	}
+	if err := normalizeOTLPJSONIDs(tree); err != nil {
+		return err
+	}
+	normalized, err := json.Marshal(tree)
+	if err != nil {
+		return err
+	}
+	return protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(normalized, req)
+}
+
+// normalizeOTLPJSONIDs walks a decoded OTLP/JSON document and converts hex-encoded
+// traceId, spanId, and parentSpanId values to base64 in place.
+func normalizeOTLPJSONIDs(node any) error {
+	switch v := node.(type) {
+	case map[string]any:
+		for key, child := range v {
+			switch key {
+			case "traceId", "spanId", "parentSpanId":
+				if s, ok := child.(string); ok && s != "" {
+					raw, err := hex.DecodeString(s)
+					if err != nil {
+						return fmt.Errorf("decoding %s '%s': %w", key, s, err)
+					}
+					v[key] = base64.StdEncoding.EncodeToString(raw)
+				}
+			default:
+				if err := normalizeOTLPJSONIDs(child); err != nil {
+					return err
+				}
+			}
+		}
+	case []any:
+		for _, child := range v {
+			if err := normalizeOTLPJSONIDs(child); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// otlpToReadOnlySpans converts an OTLP export request into SDK read-only spans
+// suitable for sdktrace.SpanExporter.ExportSpans.
+func otlpToReadOnlySpans(req *collectortracepb.ExportTraceServiceRequest) ([]sdktrace.ReadOnlySpan, error) {
+	var stubs tracetest.SpanStubs
+	for _, rs := range req.GetResourceSpans() {
+		res := resource.NewWithAttributes(rs.GetSchemaUrl(), otlpAttributes(rs.GetResource().GetAttributes())...)
+		for _, ss := range rs.GetScopeSpans() {
+			scope := instrumentation.Scope{
+				Name:      ss.GetScope().GetName(),
+				Version:   ss.GetScope().GetVersion(),
+				SchemaURL: ss.GetSchemaUrl(),
+			}
+			for _, span := range ss.GetSpans() {
+				stub, err := otlpSpanStub(span)
+				if err != nil {
+					return nil, err
+				}
+				stub.Resource = res
+				stub.InstrumentationScope = scope
+				stubs = append(stubs, stub)
+			}
+		}
+	}
+	return stubs.Snapshots(), nil
+}
+
+// otlpSpanStub converts a single OTLP span into a tracetest.SpanStub.
+func otlpSpanStub(span *tracepb.Span) (tracetest.SpanStub, error) {
+	var traceID trace.TraceID
+	var spanID trace.SpanID
+	if len(span.GetTraceId()) != len(traceID) || len(span.GetSpanId()) != len(spanID) {
+		return tracetest.SpanStub{}, errors.New("span has an invalid trace or span ID length")
+	}
+	copy(traceID[:], span.GetTraceId())
+	copy(spanID[:], span.GetSpanId())
+
+	traceState, _ := trace.ParseTraceState(span.GetTraceState()) // An invalid tracestate is dropped, not fatal.
+	stub := tracetest.SpanStub{
+		Name: span.GetName(),
+		SpanContext: trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID:    traceID,
+			SpanID:     spanID,
+			TraceFlags: trace.FlagsSampled,
+			TraceState: traceState,
+		}),
+		SpanKind:          trace.SpanKind(span.GetKind()), // OTLP and OTel API span kind values are aligned.
+		StartTime:         time.Unix(0, int64(span.GetStartTimeUnixNano())),
+		EndTime:           time.Unix(0, int64(span.GetEndTimeUnixNano())),
+		Attributes:        otlpAttributes(span.GetAttributes()),
+		DroppedAttributes: int(span.GetDroppedAttributesCount()),
+		DroppedEvents:     int(span.GetDroppedEventsCount()),
+		DroppedLinks:      int(span.GetDroppedLinksCount()),
+	}
+
+	if parent := span.GetParentSpanId(); len(parent) == len(spanID) {
+		var parentID trace.SpanID
+		copy(parentID[:], parent)
+		stub.Parent = trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID:    traceID,
+			SpanID:     parentID,
+			TraceFlags: trace.FlagsSampled,
+			Remote:     true,
+		})
+	}
+
+	for _, event := range span.GetEvents() {
+		stub.Events = append(stub.Events, sdktrace.Event{
+			Name:                  event.GetName(),
+			Attributes:            otlpAttributes(event.GetAttributes()),
+			DroppedAttributeCount: int(event.GetDroppedAttributesCount()),
+			Time:                  time.Unix(0, int64(event.GetTimeUnixNano())),
+		})
+	}
+
+	for _, link := range span.GetLinks() {
+		var linkTraceID trace.TraceID
+		var linkSpanID trace.SpanID
+		if len(link.GetTraceId()) != len(linkTraceID) || len(link.GetSpanId()) != len(linkSpanID) {
+			continue // Skip malformed links rather than rejecting the whole span.
+		}
+		copy(linkTraceID[:], link.GetTraceId())
+		copy(linkSpanID[:], link.GetSpanId())
+		linkTraceState, _ := trace.ParseTraceState(link.GetTraceState())
+		stub.Links = append(stub.Links, sdktrace.Link{
+			SpanContext: trace.NewSpanContext(trace.SpanContextConfig{
+				TraceID:    linkTraceID,
+				SpanID:     linkSpanID,
+				TraceState: linkTraceState,
+				Remote:     true,
+			}),
+			Attributes:            otlpAttributes(link.GetAttributes()),
+			DroppedAttributeCount: int(link.GetDroppedAttributesCount()),
+		})
+	}
+
+	switch span.GetStatus().GetCode() {
+	case tracepb.Status_STATUS_CODE_OK:
+		stub.Status = sdktrace.Status{Code: codes.Ok}
+	case tracepb.Status_STATUS_CODE_ERROR:
+		stub.Status = sdktrace.Status{Code: codes.Error, Description: span.GetStatus().GetMessage()}
+	}
+
+	return stub, nil
+}
+
+// otlpAttributes converts OTLP key-values into OTel attributes.
+func otlpAttributes(kvs []*commonpb.KeyValue) []attribute.KeyValue {
+	if len(kvs) == 0 {
+		return nil
+	}
+	attrs := make([]attribute.KeyValue, 0, len(kvs))
+	for _, kv := range kvs {
+		attrs = append(attrs, attribute.KeyValue{Key: attribute.Key(kv.GetKey()), Value: otlpAttributeValue(kv.GetValue())})
+	}
+	return attrs
+}
+
+// otlpAttributeValue converts an OTLP AnyValue into an OTel attribute value.
+// Homogeneous arrays of primitives map to slice values; bytes, key-value lists,
+// and mixed arrays, which OTel attributes cannot represent, are encoded as strings.
+func otlpAttributeValue(v *commonpb.AnyValue) attribute.Value {
+	switch val := v.GetValue().(type) {
+	case *commonpb.AnyValue_StringValue:
+		return attribute.StringValue(val.StringValue)
+	case *commonpb.AnyValue_BoolValue:
+		return attribute.BoolValue(val.BoolValue)
+	case *commonpb.AnyValue_IntValue:
+		return attribute.Int64Value(val.IntValue)
+	case *commonpb.AnyValue_DoubleValue:
+		return attribute.Float64Value(val.DoubleValue)
+	case *commonpb.AnyValue_BytesValue:
+		return attribute.StringValue(base64.StdEncoding.EncodeToString(val.BytesValue))
+	case *commonpb.AnyValue_ArrayValue:
+		if value, ok := otlpHomogeneousArray(val.ArrayValue.GetValues()); ok {
+			return value
+		}
+	case nil:
+		return attribute.StringValue("")
+	}
+	return attribute.StringValue(protojson.Format(v))
+}
+
+// otlpHomogeneousArray converts an OTLP array whose elements share a primitive type
+// into an OTel slice value. It reports false for empty or mixed-type arrays.
+func otlpHomogeneousArray(values []*commonpb.AnyValue) (attribute.Value, bool) {
+	if len(values) == 0 {
+		return attribute.Value{}, false
+	}
+	switch values[0].GetValue().(type) {
+	case *commonpb.AnyValue_StringValue:
+		out := make([]string, 0, len(values))
+		for _, v := range values {
+			s, ok := v.GetValue().(*commonpb.AnyValue_StringValue)
+			if !ok {
+				return attribute.Value{}, false
+			}
+			out = append(out, s.StringValue)
+		}
+		return attribute.StringSliceValue(out), true
+	case *commonpb.AnyValue_BoolValue:
+		out := make([]bool, 0, len(values))
+		for _, v := range values {
+			b, ok := v.GetValue().(*commonpb.AnyValue_BoolValue)
+			if !ok {
+				return attribute.Value{}, false
+			}
+			out = append(out, b.BoolValue)
+		}
+		return attribute.BoolSliceValue(out), true
+	case *commonpb.AnyValue_IntValue:
+		out := make([]int64, 0, len(values))
+		for _, v := range values {
+			i, ok := v.GetValue().(*commonpb.AnyValue_IntValue)
+			if !ok {
+				return attribute.Value{}, false
+			}
+			out = append(out, i.IntValue)
+		}
+		return attribute.Int64SliceValue(out), true
+	case *commonpb.AnyValue_DoubleValue:
+		out := make([]float64, 0, len(values))
+		for _, v := range values {
+			d, ok := v.GetValue().(*commonpb.AnyValue_DoubleValue)
+			if !ok {
+				return attribute.Value{}, false
+			}
+			out = append(out, d.DoubleValue)
+		}
+		return attribute.Float64SliceValue(out), true
+	}
+	return attribute.Value{}, false
+}
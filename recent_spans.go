@@ -0,0 +1,98 @@
+// Package xyliumotel provides the OpenTelemetry connector for the Xylium framework.
+// This file contains the in-memory ring buffer of recently completed spans (see Config.KeepRecentSpans).
+package xyliumotel
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// SpanSummary is a compact description of a completed span, as returned by Connector.RecentSpans.
+type SpanSummary struct {
+	Name              string
+	TraceID           trace.TraceID
+	SpanID            trace.SpanID
+	Kind              trace.SpanKind
+	StartTime         time.Time
+	Duration          time.Duration
+	StatusCode        codes.Code
+	StatusDescription string
+}
+
+// recentSpansProcessor is a SpanProcessor retaining summaries of the last completed spans
+// in a fixed-size ring buffer. It never exports anything.
+type recentSpansProcessor struct {
+	mu    sync.Mutex
+	spans []SpanSummary // Ring buffer; len(spans) is the capacity
+	next  int           // Index the next summary is written to
+	count int           // Number of valid entries, up to len(spans)
+}
+
+// newRecentSpansProcessor returns a recentSpansProcessor retaining up to `size` spans.
+func newRecentSpansProcessor(size int) *recentSpansProcessor {
+	return &recentSpansProcessor{spans: make([]SpanSummary, size)}
+}
+
+// OnStart does nothing. Implements sdktrace.SpanProcessor.
+func (p *recentSpansProcessor) OnStart(context.Context, sdktrace.ReadWriteSpan) {}
+
+// OnEnd records a summary of the completed span, overwriting the oldest one when full.
+// Implements sdktrace.SpanProcessor.
+func (p *recentSpansProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	status := s.Status()
+	summary := SpanSummary{
+		Name:              s.Name(),
+		TraceID:           s.SpanContext().TraceID(),
+		SpanID:            s.SpanContext().SpanID(),
+		Kind:              s.SpanKind(),
+		StartTime:         s.StartTime(),
+		Duration:          s.EndTime().Sub(s.StartTime()),
+		StatusCode:        status.Code,
+		StatusDescription: status.Description,
+	}
+
+	p.mu.Lock()
+	p.spans[p.next] = summary
+	p.next = (p.next + 1) % len(p.spans)
+	if p.count < len(p.spans) {
+		p.count++
+	}
+	p.mu.Unlock()
+}
+
+// Shutdown does nothing; retained summaries stay queryable. Implements sdktrace.SpanProcessor.
+func (p *recentSpansProcessor) Shutdown(context.Context) error { return nil }
+
+// ForceFlush does nothing. Implements sdktrace.SpanProcessor.
+func (p *recentSpansProcessor) ForceFlush(context.Context) error { return nil }
+
+// recent returns up to n retained summaries, most recent first. n <= 0 returns all of them.
+func (p *recentSpansProcessor) recent(n int) []SpanSummary {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if n <= 0 || n > p.count {
+		n = p.count
+	}
+	out := make([]SpanSummary, n)
+	for i := 0; i < n; i++ {
+		out[i] = p.spans[(p.next-1-i+len(p.spans))%len(p.spans)]
+	}
+	return out
+}
+
+// RecentSpans returns summaries of up to n of the most recently completed spans, most recent
+// first; n <= 0 returns every retained span. It is meant for live debugging without a collector,
+// e.g. behind a `/debug/traces` admin endpoint. Spans are only retained when Config.KeepRecentSpans
+// is positive and the TracerProvider is managed internally; nil is returned otherwise.
+// Spans withheld from export by Config.DropSpanPredicate are still retained.
+func (c *Connector) RecentSpans(n int) []SpanSummary {
+	if c.recentSpans == nil {
+		return nil
+	}
+	return c.recentSpans.recent(n)
+}
@@ -0,0 +1,104 @@
+//go:build redis
+
+// Package xyliumotel provides the OpenTelemetry connector for the Xylium framework.
+// This file contains a go-redis hook for instrumenting Redis commands.
+// It is only compiled with the `redis` build tag (go build -tags redis), so applications
+// that do not use go-redis do not pull in the dependency.
+package xyliumotel
+
+import (
+	"context"
+	"errors"
+	"net"
+
+	"github.com/redis/go-redis/v9"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0" // Consistent with otel.go
+	"go.opentelemetry.io/otel/trace"
+	tracenoop "go.opentelemetry.io/otel/trace/noop"
+)
+
+// defaultRedisTracerName is the name used for the tracer within the Redis hook.
+const defaultRedisTracerName = "xylium.otel.redis"
+
+// RedisHook returns a go-redis hook that creates a client span for every Redis command
+// (and one span per pipeline), using the connector's tracer provider and resource.
+// Register it with `rdb.AddHook(connector.RedisHook())`.
+//
+// Spans are children of the span in the context passed to the command (e.g., c.GoContext()
+// within a handler), are named after the command (e.g., "get"), and carry `db.system=redis`
+// and `db.operation.name` (the semantic conventions' name for the former `db.operation`).
+// Command arguments are never recorded, as they may contain sensitive data.
+// A redis.Nil reply (key not found) is not treated as an error.
+//
+// RedisHook is only available when building with the `redis` build tag.
+func (connector *Connector) RedisHook() redis.Hook {
+	var tracer trace.Tracer
+	if connector.IsNoOp() {
+		// If the connector is in NoOp mode, commands are passed through without spans.
+		tracer = tracenoop.NewTracerProvider().Tracer(defaultRedisTracerName)
+	} else {
+		tracer = connector.GetTracer(defaultRedisTracerName, trace.WithInstrumentationVersion(Version))
+	}
+	return &redisHook{tracer: tracer}
+}
+
+// redisHook implements redis.Hook.
+type redisHook struct {
+	tracer trace.Tracer
+}
+
+// DialHook passes connection dialing through unchanged.
+// Implements redis.Hook.
+func (h *redisHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return next(ctx, network, addr)
+	}
+}
+
+// ProcessHook wraps a single command in a client span.
+// Implements redis.Hook.
+func (h *redisHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		ctx, span := h.tracer.Start(ctx, cmd.FullName(),
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(semconv.DBSystemRedis, semconv.DBOperationName(cmd.Name())),
+		)
+		defer span.End()
+
+		err := next(ctx, cmd)
+		recordRedisError(span, err)
+		return err
+	}
+}
+
+// ProcessPipelineHook wraps a pipeline (or transaction) in a single client span.
+// Implements redis.Hook.
+func (h *redisHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		ctx, span := h.tracer.Start(ctx, "pipeline",
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				semconv.DBSystemRedis,
+				semconv.DBOperationName("pipeline"),
+				attribute.Int("db.redis.pipeline_length", len(cmds)),
+			),
+		)
+		defer span.End()
+
+		err := next(ctx, cmds)
+		recordRedisError(span, err)
+		return err
+	}
+}
+
+// recordRedisError records a command error on the span, ignoring redis.Nil.
+func recordRedisError(span trace.Span, err error) {
+	if err == nil || errors.Is(err, redis.Nil) {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
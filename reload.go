@@ -0,0 +1,110 @@
+// Package xyliumotel provides the OpenTelemetry connector for the Xylium framework.
+// This file contains runtime reloading of the span exporter of an internally managed TracerProvider.
+package xyliumotel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// reloadableSpanExporter wraps the exporter of an internally managed TracerProvider so it can be
+// replaced at runtime (see Connector.ReloadExporter), since SDK batch processors do not support
+// replacing their exporter. Exports hold a read lock, so a replaced exporter is never shut down
+// while an export to it is still in flight.
+type reloadableSpanExporter struct {
+	mu       sync.RWMutex
+	exporter sdktrace.SpanExporter
+}
+
+// newReloadableSpanExporter wraps exporter in a reloadableSpanExporter.
+func newReloadableSpanExporter(exporter sdktrace.SpanExporter) *reloadableSpanExporter {
+	return &reloadableSpanExporter{exporter: exporter}
+}
+
+// swap replaces the wrapped exporter and returns the previous one.
+func (e *reloadableSpanExporter) swap(exporter sdktrace.SpanExporter) sdktrace.SpanExporter {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	previous := e.exporter
+	e.exporter = exporter
+	return previous
+}
+
+// ExportSpans exports the spans with the current exporter.
+// Implements sdktrace.SpanExporter.
+func (e *reloadableSpanExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.exporter.ExportSpans(ctx, spans)
+}
+
+// Shutdown shuts down the current exporter.
+// Implements sdktrace.SpanExporter.
+func (e *reloadableSpanExporter) Shutdown(ctx context.Context) error {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.exporter.Shutdown(ctx)
+}
+
+// ReloadExporter repoints trace export to a new OTLP collector configuration without restarting
+// the process, e.g. after a config push with a new collector endpoint. It builds a new OTLP gRPC
+// exporter from newOTLP, flushes the spans buffered for the current exporter, atomically swaps the
+// exporters, and shuts down the old one. The TracerProvider itself is kept, so tracers already
+// obtained from the connector (including the middleware's) continue to work unchanged.
+//
+// If the new exporter cannot be created, an error is returned and the current exporter stays in
+// place. It is only supported for an internally managed TracerProvider using ExporterOTLPGRPC.
+// The logs signal keeps its original exporter. Subsequent ProbeCollector calls use newOTLP.
+// After Close, ReloadExporter returns an error.
+func (c *Connector) ReloadExporter(newOTLP OTLPConfig) error {
+	if c.tracerProvider == nil || c.reloadableExporter == nil {
+		return errors.New("xylium-otel: ReloadExporter requires a TracerProvider managed by this connector")
+	}
+	if c.config.Exporter != ExporterOTLPGRPC {
+		return fmt.Errorf("xylium-otel: ReloadExporter is only supported for the '%s' exporter (configured: '%s')", ExporterOTLPGRPC, c.config.Exporter)
+	}
+	if err := validateOTLPConfig(newOTLP, "OTLPConfig"); err != nil {
+		return err
+	}
+	if newOTLP.Timeout <= 0 {
+		newOTLP.Timeout = 10 * time.Second
+	}
+
+	c.reloadMu.Lock()
+	defer c.reloadMu.Unlock()
+	if c.closed {
+		return errors.New("xylium-otel: ReloadExporter called after Close")
+	}
+
+	exporter, err := c.newOTLPTraceExporter(newOTLP)
+	if err != nil {
+		return fmt.Errorf("xylium-otel: reloading exporter: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.config.ShutdownTimeout)
+	defer cancel()
+	// Deliver spans that were already buffered to the collector they were recorded for.
+	if ferr := c.tracerProvider.ForceFlush(ctx); ferr != nil {
+		c.config.AppLogger.Warnf("xylium-otel: Failed to flush spans to '%s' before reloading the exporter: %v", c.config.OTLP.Endpoint, ferr)
+	}
+	previous := c.reloadableExporter.swap(exporter)
+	previousEndpoint := c.config.OTLP.Endpoint
+	c.config.OTLP = newOTLP
+	if serr := previous.Shutdown(ctx); serr != nil {
+		c.config.AppLogger.Warnf("xylium-otel: Failed to shut down the previous exporter for '%s' after reload: %v", previousEndpoint, serr)
+	}
+	c.config.AppLogger.Infof("xylium-otel: Trace exporter reloaded (Endpoint: '%s' -> '%s').", previousEndpoint, newOTLP.Endpoint)
+	return nil
+}
+
+// currentOTLPConfig returns the trace OTLP configuration, which ReloadExporter may replace at runtime.
+func (c *Connector) currentOTLPConfig() OTLPConfig {
+	c.reloadMu.Lock()
+	defer c.reloadMu.Unlock()
+	return c.config.OTLP
+}
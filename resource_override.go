@@ -0,0 +1,102 @@
+// Package xyliumotel provides the OpenTelemetry connector for the Xylium framework.
+// This file contains per-tracer service name overrides for multi-module processes.
+package xyliumotel
+
+import (
+	"context"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0" // Consistent with otel.go
+	"go.opentelemetry.io/otel/trace"
+)
+
+// serviceNameScopeAttributeKey is the instrumentation scope attribute carrying a
+// per-tracer service name override (see WithServiceName).
+const serviceNameScopeAttributeKey = attribute.Key("xylium.service.name")
+
+// WithServiceName returns a trace.TracerOption for Connector.GetTracer that tags all spans of the
+// resulting tracer with a different `service.name` than Config.ServiceName, while sharing the
+// connector's TracerProvider and exporter. This is intended for modular monoliths running several
+// logical services in one process:
+//
+//	ordersTracer := connector.GetTracer("orders", xyliumotel.WithServiceName("orders-service"))
+//
+// In the OpenTelemetry SDK a Resource belongs to the TracerProvider, so it cannot be changed per
+// tracer or per span (span processors only see a read-only Resource). Instead, the override is
+// recorded as the `xylium.service.name` instrumentation scope attribute, and the exporter of the
+// internally managed TracerProvider re-stamps matching spans with the connector's resource merged
+// with the overridden `service.name` before export. With an external TracerProvider the scope
+// attribute is still set, but the resource is not rewritten.
+//
+// WithServiceName is implemented with trace.WithInstrumentationAttributes; passing another
+// WithInstrumentationAttributes option after it to the same GetTracer call replaces it.
+func WithServiceName(name string) trace.TracerOption {
+	return trace.WithInstrumentationAttributes(serviceNameScopeAttributeKey.String(name))
+}
+
+// serviceNameOverrideExporter wraps a SpanExporter and replaces the Resource of spans whose
+// instrumentation scope carries a service name override (see WithServiceName).
+type serviceNameOverrideExporter struct {
+	sdktrace.SpanExporter
+	resources sync.Map // Overridden service name (string) -> merged *resource.Resource
+}
+
+// newServiceNameOverrideExporter returns `exporter` wrapped to honor per-tracer service name overrides.
+func newServiceNameOverrideExporter(exporter sdktrace.SpanExporter) *serviceNameOverrideExporter {
+	return &serviceNameOverrideExporter{SpanExporter: exporter}
+}
+
+// ExportSpans re-stamps overridden spans with their service's resource, then exports
+// the batch with the wrapped exporter. Batches without overrides are passed through unchanged.
+// Implements sdktrace.SpanExporter.
+func (e *serviceNameOverrideExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	var rewritten []sdktrace.ReadOnlySpan
+	for i, span := range spans {
+		scopeAttrs := span.InstrumentationScope().Attributes
+		name, ok := scopeAttrs.Value(serviceNameScopeAttributeKey)
+		if !ok || name.AsString() == "" {
+			if rewritten != nil {
+				rewritten = append(rewritten, span)
+			}
+			continue
+		}
+		if rewritten == nil {
+			rewritten = make([]sdktrace.ReadOnlySpan, i, len(spans))
+			copy(rewritten, spans[:i])
+		}
+		rewritten = append(rewritten, resourceOverrideSpan{ReadOnlySpan: span, resource: e.resourceFor(span.Resource(), name.AsString())})
+	}
+	if rewritten == nil {
+		return e.SpanExporter.ExportSpans(ctx, spans)
+	}
+	return e.SpanExporter.ExportSpans(ctx, rewritten)
+}
+
+// resourceFor returns `base` merged with the overridden service name, caching the result per name.
+// All spans of a TracerProvider share the same base resource, so caching by name is sufficient.
+func (e *serviceNameOverrideExporter) resourceFor(base *resource.Resource, serviceName string) *resource.Resource {
+	if cached, ok := e.resources.Load(serviceName); ok {
+		return cached.(*resource.Resource)
+	}
+	merged, err := resource.Merge(base, resource.NewSchemaless(semconv.ServiceName(serviceName)))
+	if err != nil {
+		// Merge only fails on conflicting schema URLs; the schemaless override cannot conflict.
+		merged = base
+	}
+	actual, _ := e.resources.LoadOrStore(serviceName, merged)
+	return actual.(*resource.Resource)
+}
+
+// resourceOverrideSpan is a ReadOnlySpan whose Resource is replaced.
+type resourceOverrideSpan struct {
+	sdktrace.ReadOnlySpan
+	resource *resource.Resource
+}
+
+// Resource returns the overridden resource.
+func (s resourceOverrideSpan) Resource() *resource.Resource {
+	return s.resource
+}
@@ -0,0 +1,489 @@
+// Package xyliumotel provides the OpenTelemetry connector for the Xylium framework.
+// This file contains the sampler wrapper used by internally managed TracerProviders.
+package xyliumotel
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// samplingOverrideContextKey is the context key used to override the configured Sampler's
+// decision for the next span started from a context. Its value is a bool: true to record
+// and sample, false to drop.
+type samplingOverrideContextKey struct{}
+
+// withForceSample returns a copy of ctx that instructs the connector's sampler
+// to always record and sample spans started from it.
+func withForceSample(ctx context.Context) context.Context {
+	return withSamplingOverride(ctx, true)
+}
+
+// withSamplingOverride returns a copy of ctx that instructs the connector's sampler
+// to sample (true) or drop (false) spans started from it, regardless of the configured Sampler.
+func withSamplingOverride(ctx context.Context, sample bool) context.Context {
+	return context.WithValue(ctx, samplingOverrideContextKey{}, sample)
+}
+
+// connectorSampler wraps the configured Sampler of an internally managed TracerProvider.
+// It honors sampling overrides made by the middleware (e.g., force-sampling large requests
+// or per-route overrides) and otherwise delegates to the base Sampler.
+// The base Sampler can be swapped atomically at runtime (see Connector.SetSampler),
+// since SDK TracerProviders do not support replacing their sampler.
+type connectorSampler struct {
+	base   atomic.Pointer[samplerBox]
+	pinned *pinnedTraceIDs // Trace IDs that are always sampled (see Connector.PinTraceID), if any
+}
+
+// samplerBox holds a Sampler so that samplers of different concrete types can be
+// stored in the same atomic.Pointer.
+type samplerBox struct {
+	sampler sdktrace.Sampler
+}
+
+// newConnectorSampler wraps base in a connectorSampler.
+func newConnectorSampler(base sdktrace.Sampler) *connectorSampler {
+	s := &connectorSampler{}
+	s.setBase(base)
+	return s
+}
+
+// setBase atomically replaces the base Sampler.
+func (s *connectorSampler) setBase(base sdktrace.Sampler) {
+	s.base.Store(&samplerBox{sampler: base})
+}
+
+// ShouldSample implements sdktrace.Sampler.
+func (s *connectorSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	// Pinned traces are kept regardless of any other sampling decision.
+	if s.pinned != nil && s.pinned.contains(p.TraceID) {
+		return sdktrace.SamplingResult{
+			Decision:   sdktrace.RecordAndSample,
+			Tracestate: trace.SpanContextFromContext(p.ParentContext).TraceState(),
+		}
+	}
+	if sample, overridden := p.ParentContext.Value(samplingOverrideContextKey{}).(bool); overridden {
+		decision := sdktrace.Drop
+		if sample {
+			decision = sdktrace.RecordAndSample
+		}
+		return sdktrace.SamplingResult{
+			Decision:   decision,
+			Tracestate: trace.SpanContextFromContext(p.ParentContext).TraceState(),
+		}
+	}
+	return s.base.Load().sampler.ShouldSample(p)
+}
+
+// Description implements sdktrace.Sampler.
+func (s *connectorSampler) Description() string {
+	return "XyliumConnectorSampler{" + s.base.Load().sampler.Description() + "}"
+}
+
+// SetSampler atomically replaces the sampling strategy of the internally managed TracerProvider
+// at runtime, e.g., to temporarily raise sampling to 100% during an incident without a restart.
+// Spans already started are unaffected. Middleware sampling overrides (ShouldSample,
+// per-route overrides, AlwaysSampleAboveBytes) continue to take precedence.
+// It has no effect (and logs a warning) if the connector does not manage its own TracerProvider,
+// or if `s` is nil.
+func (c *Connector) SetSampler(s sdktrace.Sampler) {
+	if c.sampler == nil || s == nil {
+		if c.config.AppLogger != nil {
+			c.config.AppLogger.Warn("xylium-otel: SetSampler ignored: sampler is nil or the TracerProvider is not managed by this connector.")
+		}
+		return
+	}
+	c.sampler.setBase(s)
+	if c.config.AppLogger != nil {
+		c.config.AppLogger.Infof("xylium-otel: Sampler replaced at runtime with %s.", s.Description())
+	}
+}
+
+// SetSamplingRatio is a convenience for SetSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))).
+// A ratio >= 1 samples every trace; a ratio <= 0 samples none (unless the parent was sampled).
+func (c *Connector) SetSamplingRatio(ratio float64) {
+	c.SetSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio)))
+}
+
+// routeSampleOverride is a temporary sampling ratio for a single route.
+type routeSampleOverride struct {
+	ratio     float64
+	expiresAt time.Time
+}
+
+// SetRouteSampleOverride temporarily overrides the sampling ratio for requests whose path equals
+// `route` exactly, for the given `ttl`. Only static routes (e.g., "/api/checkout") can be targeted:
+// Xylium does not expose the matched route pattern to middleware, so a pattern such as
+// "/users/:id" never matches and is logged as a warning.
+// A ratio of 1 samples every request (e.g., for a targeted debug campaign) and 0 drops them all.
+// The override expires automatically after `ttl`; a `ttl` of zero or less removes any existing
+// override for the route immediately.
+// Overrides are only honored by the connector's internally managed TracerProvider.
+func (c *Connector) SetRouteSampleOverride(route string, ratio float64, ttl time.Duration) {
+	c.routeOverridesMu.Lock()
+	defer c.routeOverridesMu.Unlock()
+
+	if ttl <= 0 {
+		delete(c.routeOverrides, route)
+		if c.config.AppLogger != nil {
+			c.config.AppLogger.Infof("xylium-otel: Sampling override for route '%s' removed.", route)
+		}
+		return
+	}
+	if strings.ContainsAny(route, ":*") && c.config.AppLogger != nil {
+		c.config.AppLogger.Warnf("xylium-otel: Sampling override route '%s' looks like a route pattern; overrides match exact request paths only, so it will not match any request.", route)
+	}
+	ratio = math.Max(0, math.Min(1, ratio))
+	if c.routeOverrides == nil {
+		c.routeOverrides = make(map[string]routeSampleOverride)
+	}
+	c.routeOverrides[route] = routeSampleOverride{ratio: ratio, expiresAt: time.Now().Add(ttl)}
+	if c.config.AppLogger != nil {
+		c.config.AppLogger.Infof("xylium-otel: Sampling override for route '%s' set to ratio %.3f for %v.", route, ratio, ttl)
+	}
+}
+
+// routeSampleOverrideFor returns the active sampling ratio override for `route`, if any.
+// Expired overrides are removed lazily.
+func (c *Connector) routeSampleOverrideFor(route string) (float64, bool) {
+	c.routeOverridesMu.RLock()
+	override, exists := c.routeOverrides[route]
+	c.routeOverridesMu.RUnlock()
+	if !exists {
+		return 0, false
+	}
+	if time.Now().After(override.expiresAt) {
+		c.routeOverridesMu.Lock()
+		// Re-check under the write lock in case the override was refreshed concurrently.
+		if current, ok := c.routeOverrides[route]; ok && time.Now().After(current.expiresAt) {
+			delete(c.routeOverrides, route)
+		}
+		c.routeOverridesMu.Unlock()
+		return 0, false
+	}
+	return override.ratio, true
+}
+
+// pinnedTraceIDs is a set of trace IDs that are force-sampled until their expiry.
+type pinnedTraceIDs struct {
+	mu    sync.RWMutex
+	count atomic.Int64 // Number of entries in expiry, allowing a lock-free check when empty
+	// expiry maps each pinned trace ID to the time its pin expires.
+	expiry map[trace.TraceID]time.Time
+}
+
+// pin force-samples `id` until now+ttl, or unpins it if ttl <= 0.
+func (p *pinnedTraceIDs) pin(id trace.TraceID, ttl time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if ttl <= 0 {
+		delete(p.expiry, id)
+	} else {
+		if p.expiry == nil {
+			p.expiry = make(map[trace.TraceID]time.Time)
+		}
+		p.expiry[id] = time.Now().Add(ttl)
+	}
+	p.count.Store(int64(len(p.expiry)))
+}
+
+// contains reports whether `id` is pinned. Expired pins are removed lazily.
+func (p *pinnedTraceIDs) contains(id trace.TraceID) bool {
+	if p.count.Load() == 0 {
+		return false
+	}
+	p.mu.RLock()
+	expiresAt, exists := p.expiry[id]
+	p.mu.RUnlock()
+	if !exists {
+		return false
+	}
+	if time.Now().After(expiresAt) {
+		p.mu.Lock()
+		// Re-check under the write lock in case the pin was refreshed concurrently.
+		if current, ok := p.expiry[id]; ok && time.Now().After(current) {
+			delete(p.expiry, id)
+			p.count.Store(int64(len(p.expiry)))
+		}
+		p.mu.Unlock()
+		return false
+	}
+	return true
+}
+
+// PinTraceID force-samples every span belonging to the trace `id` for the given `ttl`,
+// regardless of the configured Sampler and middleware sampling overrides. This is intended for
+// targeted retention while investigating a specific reported issue (e.g., a trace ID from a
+// support ticket) without raising global sampling. Requests continuing the pinned trace are kept
+// as soon as they reach the service. A `ttl` of zero or less removes the pin immediately.
+// Pins are only honored by the connector's internally managed TracerProvider.
+func (c *Connector) PinTraceID(id trace.TraceID, ttl time.Duration) {
+	c.pinnedTraces.pin(id, ttl)
+	if c.config.AppLogger == nil {
+		return
+	}
+	if ttl <= 0 {
+		c.config.AppLogger.Infof("xylium-otel: Trace '%s' unpinned.", id)
+	} else {
+		c.config.AppLogger.Infof("xylium-otel: Trace '%s' pinned (always sampled) for %v.", id, ttl)
+	}
+}
+
+// ParentSamplingBehavior defines how a SamplingStrategy treats spans that have a parent
+// in a given state (remote or local, sampled or not sampled).
+type ParentSamplingBehavior string
+
+const (
+	// ParentSamplingDefault applies the SDK's ParentBased default for the parent state:
+	// sample if the parent was sampled, drop if it was not.
+	ParentSamplingDefault ParentSamplingBehavior = ""
+	// ParentSamplingAlways samples the span regardless of the parent's decision.
+	ParentSamplingAlways ParentSamplingBehavior = "always"
+	// ParentSamplingNever drops the span regardless of the parent's decision.
+	ParentSamplingNever ParentSamplingBehavior = "never"
+	// ParentSamplingRatio applies SamplingStrategy.RootRatio, as for root spans.
+	ParentSamplingRatio ParentSamplingBehavior = "ratio"
+)
+
+// SamplingStrategy describes a ParentBased sampler declaratively, as an alternative to building
+// `sdktrace.ParentBased(root, opts...)` by hand. Set it via Config.SamplingStrategy.
+type SamplingStrategy struct {
+	// RootRatio is the fraction of root spans (spans without a parent) to sample, from 0 to 1.
+	// Note that the zero value drops all roots; use 1 to always sample roots.
+	RootRatio float64
+	// RemoteParentSampled applies to spans whose remote (propagated) parent was sampled.
+	RemoteParentSampled ParentSamplingBehavior
+	// RemoteParentNotSampled applies to spans whose remote (propagated) parent was not sampled.
+	RemoteParentNotSampled ParentSamplingBehavior
+	// LocalParentSampled applies to spans whose in-process parent was sampled.
+	LocalParentSampled ParentSamplingBehavior
+	// LocalParentNotSampled applies to spans whose in-process parent was not sampled.
+	LocalParentNotSampled ParentSamplingBehavior
+}
+
+// sampler builds the ParentBased sampler described by the strategy.
+func (s SamplingStrategy) sampler() (sdktrace.Sampler, error) {
+	if s.RootRatio < 0 || s.RootRatio > 1 || math.IsNaN(s.RootRatio) {
+		return nil, fmt.Errorf("xylium-otel: SamplingStrategy.RootRatio must be between 0 and 1 (got %v)", s.RootRatio)
+	}
+	root := sdktrace.TraceIDRatioBased(s.RootRatio)
+
+	var opts []sdktrace.ParentBasedSamplerOption
+	behaviors := []struct {
+		field    string
+		behavior ParentSamplingBehavior
+		option   func(sdktrace.Sampler) sdktrace.ParentBasedSamplerOption
+	}{
+		{"RemoteParentSampled", s.RemoteParentSampled, sdktrace.WithRemoteParentSampled},
+		{"RemoteParentNotSampled", s.RemoteParentNotSampled, sdktrace.WithRemoteParentNotSampled},
+		{"LocalParentSampled", s.LocalParentSampled, sdktrace.WithLocalParentSampled},
+		{"LocalParentNotSampled", s.LocalParentNotSampled, sdktrace.WithLocalParentNotSampled},
+	}
+	for _, b := range behaviors {
+		switch b.behavior {
+		case ParentSamplingDefault:
+			// Keep the SDK's ParentBased default for this parent state.
+		case ParentSamplingAlways:
+			opts = append(opts, b.option(sdktrace.AlwaysSample()))
+		case ParentSamplingNever:
+			opts = append(opts, b.option(sdktrace.NeverSample()))
+		case ParentSamplingRatio:
+			opts = append(opts, b.option(root))
+		default:
+			return nil, fmt.Errorf("xylium-otel: unsupported SamplingStrategy.%s '%s' (supported: \"\", \"always\", \"never\", \"ratio\")", b.field, b.behavior)
+		}
+	}
+	return sdktrace.ParentBased(root, opts...), nil
+}
+
+// CompositeSampler returns a Sampler that combines a ratio-based sampler with a rate-limiting
+// sampler, e.g., "sample 10% of traces but never exceed 100 spans/sec":
+//
+//	Sampler: xyliumotel.CompositeSampler(
+//		sdktrace.TraceIDRatioBased(0.1),
+//		xyliumotel.RateLimitingSampler(100),
+//	)
+//
+// Decision precedence: `ratioSampler` is consulted first; if it drops the span, the span is dropped
+// and no rate-limit budget is consumed. Only spans accepted by `ratioSampler` are passed to
+// `rateLimitSampler`, whose decision is final. Attributes and tracestate entries from both decisions
+// are kept; for a tracestate key set by both, the rate-limiting sampler's value wins.
+// To honor sampled parents, wrap the result in sdktrace.ParentBased.
+func CompositeSampler(ratioSampler, rateLimitSampler sdktrace.Sampler) sdktrace.Sampler {
+	return &compositeSampler{ratio: ratioSampler, rateLimit: rateLimitSampler}
+}
+
+// compositeSampler applies a ratio sampler, then caps accepted spans with a rate-limiting sampler.
+type compositeSampler struct {
+	ratio     sdktrace.Sampler
+	rateLimit sdktrace.Sampler
+}
+
+// ShouldSample implements sdktrace.Sampler.
+func (s *compositeSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	ratioResult := s.ratio.ShouldSample(p)
+	if ratioResult.Decision == sdktrace.Drop {
+		return ratioResult
+	}
+	rateResult := s.rateLimit.ShouldSample(p)
+	rateResult.Attributes = slices.Concat(ratioResult.Attributes, rateResult.Attributes)
+	rateResult.Tracestate = mergeTraceState(ratioResult.Tracestate, rateResult.Tracestate)
+	return rateResult
+}
+
+// mergeTraceState returns `base` with the entries of `overlay` added, overlay values replacing
+// base values for the same key. Entries that cannot be added (e.g., when the tracestate is full)
+// are skipped.
+func mergeTraceState(base, overlay trace.TraceState) trace.TraceState {
+	merged := base
+	overlay.Walk(func(key, value string) bool {
+		if merged.Get(key) != value {
+			if next, err := merged.Insert(key, value); err == nil {
+				merged = next
+			}
+		}
+		return true
+	})
+	return merged
+}
+
+// Description implements sdktrace.Sampler.
+func (s *compositeSampler) Description() string {
+	return "CompositeSampler{" + s.ratio.Description() + "," + s.rateLimit.Description() + "}"
+}
+
+// RateLimitingSampler returns a Sampler that samples at most `spansPerSecond` spans per second,
+// using a token bucket that allows bursts of up to one second's worth of spans (at least one span).
+// Fractional rates are supported, e.g. 0.5 samples one span every two seconds.
+// Spans over the limit are dropped. A non-positive rate drops every span.
+func RateLimitingSampler(spansPerSecond float64) sdktrace.Sampler {
+	return newRateLimitingSampler(spansPerSecond, time.Now)
+}
+
+// newRateLimitingSampler returns a rateLimitingSampler reading the time from `now`.
+func newRateLimitingSampler(spansPerSecond float64, now func() time.Time) *rateLimitingSampler {
+	// The bucket must hold at least one token, or rates below 1/s could never sample.
+	capacity := math.Max(1, spansPerSecond)
+	return &rateLimitingSampler{
+		spansPerSecond: spansPerSecond,
+		capacity:       capacity,
+		now:            now,
+		tokens:         capacity,
+		last:           now(),
+	}
+}
+
+// rateLimitingSampler is a token-bucket Sampler.
+type rateLimitingSampler struct {
+	spansPerSecond float64
+	capacity       float64          // Maximum number of tokens, i.e. the largest burst
+	now            func() time.Time // Clock used for refilling (time.Now outside of tests)
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// ShouldSample implements sdktrace.Sampler.
+func (s *rateLimitingSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	result := sdktrace.SamplingResult{
+		Decision:   sdktrace.Drop,
+		Tracestate: trace.SpanContextFromContext(p.ParentContext).TraceState(),
+	}
+	if s.spansPerSecond <= 0 {
+		return result
+	}
+
+	s.mu.Lock()
+	now := s.now()
+	s.tokens = math.Min(s.capacity, s.tokens+now.Sub(s.last).Seconds()*s.spansPerSecond)
+	s.last = now
+	if s.tokens >= 1 {
+		s.tokens--
+		result.Decision = sdktrace.RecordAndSample
+	}
+	s.mu.Unlock()
+	return result
+}
+
+// Description implements sdktrace.Sampler.
+func (s *rateLimitingSampler) Description() string {
+	return fmt.Sprintf("RateLimitingSampler{%g}", s.spansPerSecond)
+}
+
+// ParentBasedRateLimiting returns a Sampler that follows the parent's sampling decision and
+// samples root spans with RateLimitingSampler(maxPerSecond). It caps the number of new traces
+// started by this service per second regardless of request spikes, while keeping traces started
+// upstream intact. `opts` customize the behavior for each parent state as in sdktrace.ParentBased.
+// Spans continuing sampled parents do not consume the budget, so the total span volume can
+// exceed maxPerSecond.
+func ParentBasedRateLimiting(maxPerSecond float64, opts ...sdktrace.ParentBasedSamplerOption) sdktrace.Sampler {
+	return sdktrace.ParentBased(RateLimitingSampler(maxPerSecond), opts...)
+}
+
+// lockedRand is a *rand.Rand safe for concurrent use, wrapping a user-provided rand.Source.
+type lockedRand struct {
+	mu sync.Mutex
+	r  *rand.Rand
+}
+
+// newLockedRand returns a lockedRand drawing from `src`.
+func newLockedRand(src rand.Source) *lockedRand {
+	return &lockedRand{r: rand.New(src)}
+}
+
+// Float64 returns a pseudo-random number in [0.0, 1.0).
+func (l *lockedRand) Float64() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.r.Float64()
+}
+
+// Uint64 returns a pseudo-random 64-bit value.
+func (l *lockedRand) Uint64() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.r.Uint64()
+}
+
+// seededIDGenerator is an sdktrace.IDGenerator that derives trace and span IDs from a
+// user-provided rand.Source, making ID-dependent sampling decisions reproducible.
+type seededIDGenerator struct {
+	rand *lockedRand
+}
+
+// newSeededIDGenerator returns an IDGenerator drawing from `r`.
+func newSeededIDGenerator(r *lockedRand) *seededIDGenerator {
+	return &seededIDGenerator{rand: r}
+}
+
+// NewIDs returns a new, non-zero trace ID and span ID.
+// Implements sdktrace.IDGenerator.
+func (g *seededIDGenerator) NewIDs(ctx context.Context) (trace.TraceID, trace.SpanID) {
+	var traceID trace.TraceID
+	for !traceID.IsValid() {
+		binary.BigEndian.PutUint64(traceID[:8], g.rand.Uint64())
+		binary.BigEndian.PutUint64(traceID[8:], g.rand.Uint64())
+	}
+	return traceID, g.NewSpanID(ctx, traceID)
+}
+
+// NewSpanID returns a new, non-zero span ID.
+// Implements sdktrace.IDGenerator.
+func (g *seededIDGenerator) NewSpanID(ctx context.Context, traceID trace.TraceID) trace.SpanID {
+	var spanID trace.SpanID
+	for !spanID.IsValid() {
+		binary.BigEndian.PutUint64(spanID[:], g.rand.Uint64())
+	}
+	return spanID
+}
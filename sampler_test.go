@@ -0,0 +1,181 @@
+package xyliumotel
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// rootSamplingParameters returns sampling parameters for a root span.
+func rootSamplingParameters() sdktrace.SamplingParameters {
+	return sdktrace.SamplingParameters{
+		ParentContext: context.Background(),
+		TraceID:       trace.TraceID{1},
+		Name:          "operation",
+	}
+}
+
+// sampledCount calls ShouldSample n times and returns how many calls sampled.
+func sampledCount(s sdktrace.Sampler, p sdktrace.SamplingParameters, n int) int {
+	sampled := 0
+	for i := 0; i < n; i++ {
+		if s.ShouldSample(p).Decision == sdktrace.RecordAndSample {
+			sampled++
+		}
+	}
+	return sampled
+}
+
+func TestRateLimitingSamplerBurstCap(t *testing.T) {
+	sampler := newRateLimitingSampler(10, newFakeClock().Now)
+	if got := sampledCount(sampler, rootSamplingParameters(), 100); got != 10 {
+		t.Errorf("sampled %d of 100 spans in a burst, want 10", got)
+	}
+}
+
+func TestRateLimitingSamplerRefill(t *testing.T) {
+	tests := []struct {
+		name    string
+		rate    float64
+		elapsed time.Duration
+		want    int // Spans sampled after the initial burst was spent and `elapsed` passed
+	}{
+		{name: "half second at 10/s", rate: 10, elapsed: 500 * time.Millisecond, want: 5},
+		{name: "refill capped at one second of spans", rate: 10, elapsed: time.Hour, want: 10},
+		{name: "partial token at 10/s", rate: 10, elapsed: 50 * time.Millisecond, want: 0},
+		{name: "sub-1/s before a full token", rate: 0.25, elapsed: 2 * time.Second, want: 0},
+		{name: "sub-1/s after a full token", rate: 0.25, elapsed: 4 * time.Second, want: 1},
+		{name: "sub-1/s capped at one token", rate: 0.25, elapsed: time.Hour, want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := newFakeClock()
+			sampler := newRateLimitingSampler(tt.rate, clock.Now)
+			params := rootSamplingParameters()
+			sampledCount(sampler, params, 100) // Spend the initial burst.
+			if sampler.ShouldSample(params).Decision != sdktrace.Drop {
+				t.Fatal("span over the burst was sampled, want dropped")
+			}
+
+			clock.Advance(tt.elapsed)
+			if got := sampledCount(sampler, params, 100); got != tt.want {
+				t.Errorf("sampled %d spans after %v at %v/s, want %d", got, tt.elapsed, tt.rate, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateLimitingSamplerRefillOverTime(t *testing.T) {
+	clock := newFakeClock()
+	sampler := newRateLimitingSampler(0.5, clock.Now)
+	params := rootSamplingParameters()
+
+	// Poll every 100ms for 10s: one span up front, then one every 2s.
+	sampled := sampledCount(sampler, params, 1)
+	for i := 0; i < 100; i++ {
+		clock.Advance(100 * time.Millisecond)
+		sampled += sampledCount(sampler, params, 1)
+	}
+	if sampled != 6 {
+		t.Errorf("sampled %d spans over 10s at 0.5/s, want 6", sampled)
+	}
+}
+
+func TestRateLimitingSamplerNonPositiveRate(t *testing.T) {
+	for _, rate := range []float64{0, -1} {
+		if got := sampledCount(RateLimitingSampler(rate), rootSamplingParameters(), 10); got != 0 {
+			t.Errorf("RateLimitingSampler(%v) sampled %d spans, want 0", rate, got)
+		}
+	}
+}
+
+func TestRateLimitingSamplerConcurrent(t *testing.T) {
+	const rate = 50
+	sampler := RateLimitingSampler(rate)
+	params := rootSamplingParameters()
+
+	start := time.Now()
+	var sampled atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sampled.Add(int64(sampledCount(sampler, params, 200)))
+		}()
+	}
+	wg.Wait()
+
+	// The burst plus whatever was refilled while the goroutines ran.
+	limit := int64(rate + time.Since(start).Seconds()*rate)
+	if got := sampled.Load(); got < rate || got > limit {
+		t.Errorf("sampled %d spans concurrently, want between %d and %d", got, rate, limit)
+	}
+}
+
+func TestParentBasedRateLimiting(t *testing.T) {
+	sampler := ParentBasedRateLimiting(1)
+	root := rootSamplingParameters()
+	if got := sampledCount(sampler, root, 10); got != 1 {
+		t.Errorf("sampled %d of 10 root spans, want 1", got)
+	}
+
+	parent := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{2},
+		SpanID:     trace.SpanID{2},
+		TraceFlags: trace.FlagsSampled,
+		Remote:     true,
+	})
+	child := sdktrace.SamplingParameters{
+		ParentContext: trace.ContextWithRemoteSpanContext(context.Background(), parent),
+		TraceID:       parent.TraceID(),
+		Name:          "operation",
+	}
+	if got := sampledCount(sampler, child, 10); got != 10 {
+		t.Errorf("sampled %d of 10 spans with a sampled parent after the budget was spent, want 10", got)
+	}
+}
+
+// fakeClock is a manually advanced clock for token bucket tests.
+type fakeClock struct {
+	mu sync.Mutex
+	t  time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{t: time.Unix(1700000000, 0)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.t
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.t = c.t.Add(d)
+}
+
+func TestRateLimitingSamplerFractionalRate(t *testing.T) {
+	clock := newFakeClock()
+	sampler := newRateLimitingSampler(0.5, clock.Now)
+	params := rootSamplingParameters()
+
+	if got := sampledCount(sampler, params, 1); got != 1 {
+		t.Fatalf("first span sampled %d times, want 1", got)
+	}
+	if got := sampledCount(sampler, params, 10); got != 0 {
+		t.Errorf("sampled %d spans right after the first one, want 0", got)
+	}
+	clock.Advance(2 * time.Second)
+	if got := sampledCount(sampler, params, 10); got != 1 {
+		t.Errorf("sampled %d spans after 2s at 0.5/s, want 1", got)
+	}
+}
@@ -0,0 +1,112 @@
+// Package xyliumotel provides the OpenTelemetry connector for the Xylium framework.
+// This file contains the HTTP semantic conventions stability mode (old, new, or both attribute sets).
+package xyliumotel
+
+import (
+	"os"
+	"slices"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0" // Consistent with otel.go
+)
+
+// SemconvStabilityMode selects which HTTP semantic conventions the middleware emits, mirroring
+// the values of the OTel OTEL_SEMCONV_STABILITY_OPT_IN environment variable.
+type SemconvStabilityMode string
+
+const (
+	// SemconvStabilityHTTP emits only the stable HTTP conventions (e.g., `http.request.method`),
+	// as pinned by this package (semconv v1.26.0). This is the default.
+	SemconvStabilityHTTP SemconvStabilityMode = "http"
+	// SemconvStabilityHTTPDup emits both the stable and the legacy HTTP conventions
+	// (e.g., `http.request.method` and `http.method`), for migrating backends and dashboards.
+	SemconvStabilityHTTPDup SemconvStabilityMode = "http/dup"
+	// SemconvStabilityOld emits only the legacy (pre-v1.21) HTTP conventions (e.g., `http.method`),
+	// for collectors and backends that have not adopted the stable keys yet.
+	SemconvStabilityOld SemconvStabilityMode = "old"
+)
+
+// envSemconvStabilityOptIn is consulted when Config.SemconvStabilityMode is empty.
+const envSemconvStabilityOptIn = "OTEL_SEMCONV_STABILITY_OPT_IN"
+
+// semconvStabilityModeFromEnv returns the HTTP stability mode requested via
+// OTEL_SEMCONV_STABILITY_OPT_IN (a comma-separated list), or "" if it requests none.
+func semconvStabilityModeFromEnv() SemconvStabilityMode {
+	values := strings.Split(os.Getenv(envSemconvStabilityOptIn), ",")
+	for i := range values {
+		values[i] = strings.TrimSpace(values[i])
+	}
+	switch {
+	case slices.Contains(values, string(SemconvStabilityHTTPDup)):
+		return SemconvStabilityHTTPDup
+	case slices.Contains(values, string(SemconvStabilityHTTP)):
+		return SemconvStabilityHTTP
+	}
+	return ""
+}
+
+// emitsStable reports whether the stable HTTP attributes are emitted in this mode.
+func (m SemconvStabilityMode) emitsStable() bool {
+	return m != SemconvStabilityOld
+}
+
+// emitsLegacy reports whether the legacy HTTP attributes are emitted in this mode.
+func (m SemconvStabilityMode) emitsLegacy() bool {
+	return m == SemconvStabilityHTTPDup || m == SemconvStabilityOld
+}
+
+// legacyHTTPAttributeKeys maps stable HTTP server attribute keys to their legacy equivalents.
+// `url.path` and `url.query` are combined into the legacy `http.target` instead.
+var legacyHTTPAttributeKeys = map[attribute.Key]attribute.Key{
+	semconv.HTTPRequestMethodKey:      "http.method",
+	semconv.URLSchemeKey:              "http.scheme",
+	semconv.ServerAddressKey:          "net.host.name",
+	semconv.ClientAddressKey:          "http.client_ip",
+	semconv.UserAgentOriginalKey:      "http.user_agent",
+	semconv.NetworkProtocolVersionKey: "http.flavor",
+	semconv.NetworkPeerAddressKey:     "net.sock.peer.addr",
+	semconv.NetworkPeerPortKey:        "net.sock.peer.port",
+	semconv.HTTPRequestBodySizeKey:    "http.request_content_length",
+	semconv.HTTPResponseStatusCodeKey: "http.status_code",
+}
+
+// applySemconvStability rewrites the stable HTTP attributes in attrs according to mode:
+// unchanged for SemconvStabilityHTTP, with legacy equivalents appended for SemconvStabilityHTTPDup,
+// and replaced by their legacy equivalents for SemconvStabilityOld. Attributes without a legacy
+// equivalent (e.g., `http.route`) are always kept.
+func applySemconvStability(attrs []attribute.KeyValue, mode SemconvStabilityMode) []attribute.KeyValue {
+	if !mode.emitsLegacy() {
+		return attrs
+	}
+	var legacy []attribute.KeyValue
+	var path, query string
+	hasPath := false
+	for _, kv := range attrs {
+		switch kv.Key {
+		case semconv.URLPathKey:
+			path, hasPath = kv.Value.AsString(), true
+		case semconv.URLQueryKey:
+			query = kv.Value.AsString()
+		default:
+			if legacyKey, ok := legacyHTTPAttributeKeys[kv.Key]; ok {
+				legacy = append(legacy, attribute.KeyValue{Key: legacyKey, Value: kv.Value})
+			}
+		}
+	}
+	if hasPath {
+		target := path
+		if query != "" {
+			target += "?" + query
+		}
+		legacy = append(legacy, attribute.String("http.target", target))
+	}
+	if mode.emitsStable() {
+		return append(attrs, legacy...)
+	}
+	kept := slices.DeleteFunc(attrs, func(kv attribute.KeyValue) bool {
+		_, mapped := legacyHTTPAttributeKeys[kv.Key]
+		return mapped || kv.Key == semconv.URLPathKey || kv.Key == semconv.URLQueryKey
+	})
+	return append(kept, legacy...)
+}
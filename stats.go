@@ -0,0 +1,95 @@
+// Package xyliumotel provides the OpenTelemetry connector for the Xylium framework.
+// This file contains self-observability counters for the connector's span export pipeline.
+package xyliumotel
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// ConnectorStats is a snapshot of the export pipeline counters of an internally managed
+// TracerProvider, as returned by Connector.Stats. All counters are cumulative since New.
+// Spans forwarded by OTLPReceiverHandler go through the same pipeline and are included.
+type ConnectorStats struct {
+	// SuccessfulExports is the number of export calls (batches) the exporter completed without error.
+	SuccessfulExports int64
+	// FailedExports is the number of export calls (batches) that returned an error.
+	FailedExports int64
+	// ExportedSpans is the number of spans in successful export calls.
+	ExportedSpans int64
+	// DroppedSpans is the number of spans in failed export calls, which the SDK does not retry
+	// beyond the exporter's own retry policy and are therefore lost.
+	// Spans dropped by the batch processor because its queue was full are not observable
+	// through the SDK and are not included.
+	DroppedSpans int64
+	// FilteredSpans is the number of completed spans withheld from export by Config.DropSpanPredicate.
+	FilteredSpans int64
+	// LastExportLatency is the duration of the most recent export call.
+	LastExportLatency time.Duration
+	// AverageExportLatency is the mean duration of all export calls so far.
+	AverageExportLatency time.Duration
+}
+
+// exportStats holds the atomic counters backing ConnectorStats.
+type exportStats struct {
+	successfulExports  atomic.Int64
+	failedExports      atomic.Int64
+	exportedSpans      atomic.Int64
+	droppedSpans       atomic.Int64
+	filteredSpans      atomic.Int64
+	lastExportLatency  atomic.Int64 // Nanoseconds
+	totalExportLatency atomic.Int64 // Nanoseconds
+}
+
+// snapshot returns the current values of the counters.
+func (s *exportStats) snapshot() ConnectorStats {
+	stats := ConnectorStats{
+		SuccessfulExports: s.successfulExports.Load(),
+		FailedExports:     s.failedExports.Load(),
+		ExportedSpans:     s.exportedSpans.Load(),
+		DroppedSpans:      s.droppedSpans.Load(),
+		FilteredSpans:     s.filteredSpans.Load(),
+		LastExportLatency: time.Duration(s.lastExportLatency.Load()),
+	}
+	if exports := stats.SuccessfulExports + stats.FailedExports; exports > 0 {
+		stats.AverageExportLatency = time.Duration(s.totalExportLatency.Load() / exports)
+	}
+	return stats
+}
+
+// Stats returns a snapshot of the connector's span export counters, e.g. to expose on an
+// admin endpoint or to alert when DroppedSpans spikes. The counters are only maintained for an
+// internally managed TracerProvider; a zero ConnectorStats is returned otherwise.
+func (c *Connector) Stats() ConnectorStats {
+	if c.stats == nil {
+		return ConnectorStats{}
+	}
+	return c.stats.snapshot()
+}
+
+// statsSpanExporter wraps a SpanExporter and records export outcomes and latency in exportStats.
+type statsSpanExporter struct {
+	sdktrace.SpanExporter
+	stats *exportStats
+}
+
+// ExportSpans exports the spans with the wrapped exporter and records the outcome.
+// Implements sdktrace.SpanExporter.
+func (e *statsSpanExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	start := time.Now()
+	err := e.SpanExporter.ExportSpans(ctx, spans)
+	latency := int64(time.Since(start))
+	e.stats.lastExportLatency.Store(latency)
+	e.stats.totalExportLatency.Add(latency)
+	if err != nil {
+		e.stats.failedExports.Add(1)
+		e.stats.droppedSpans.Add(int64(len(spans)))
+		return err
+	}
+	e.stats.successfulExports.Add(1)
+	e.stats.exportedSpans.Add(int64(len(spans)))
+	return nil
+}
@@ -0,0 +1,60 @@
+// Package xyliumotel provides the OpenTelemetry connector for the Xylium framework.
+// This file contains helpers for testing instrumented handlers.
+package xyliumotel
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/arwahdevops/xylium-core/src/xylium"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// exporterDiscard is an internal exporter type that discards all spans. It is used by
+// NewTestConnector, where spans are captured by a SpanRecorder instead.
+const exporterDiscard ExporterType = "discard"
+
+// NewTestConnector creates a Connector for use in tests, together with a tracetest.SpanRecorder
+// that captures every span started and ended through it, so tests can assert on span names,
+// attributes, and status (e.g., via recorder.Ended()) deterministically.
+//
+// The connector manages its own TracerProvider (so sampling overrides and other middleware
+// features behave as in production), samples every root span, discards spans instead of exporting
+// them, logs only warnings and errors to io.Discard, and does not touch the global OTel providers.
+// An optional Config may be passed to customize it; its ServiceName, AppLogger, Exporter,
+// ManageGlobalProviders, and SpanProcessors fields are overridden or extended as needed. If logs
+// are enabled without a Logs.Exporter, they are written to StdoutWriter, defaulting to io.Discard.
+// NewTestConnector panics if the connector cannot be created. Call Close when done.
+func NewTestConnector(cfgs ...Config) (*Connector, *tracetest.SpanRecorder) {
+	cfg := Config{}
+	if len(cfgs) > 0 {
+		cfg = cfgs[0]
+	}
+
+	recorder := tracetest.NewSpanRecorder()
+	if cfg.ServiceName == "" {
+		cfg.ServiceName = "xylium-otel-test"
+	}
+	if cfg.AppLogger == nil {
+		cfg.AppLogger = xylium.NewDefaultLoggerWithConfig(xylium.LoggerConfig{Level: xylium.LevelWarn, Output: io.Discard})
+	}
+	cfg.Exporter = exporterDiscard
+	if cfg.Logs.Enabled && cfg.Logs.Exporter == "" {
+		// Logs would otherwise inherit the trace-only discard exporter.
+		cfg.Logs.Exporter = ExporterStdout
+		if cfg.StdoutWriter == nil {
+			cfg.StdoutWriter = io.Discard
+		}
+	}
+	manageGlobal := false
+	cfg.ManageGlobalProviders = &manageGlobal
+	cfg.SpanProcessors = append([]sdktrace.SpanProcessor{recorder}, cfg.SpanProcessors...)
+
+	connector, err := New(cfg)
+	if err != nil {
+		panic(fmt.Sprintf("xylium-otel: creating test connector: %v", err))
+	}
+	return connector, recorder
+}
@@ -0,0 +1,8 @@
+// Package xyliumotel provides the OpenTelemetry connector for the Xylium framework.
+// This file contains the version of the connector.
+package xyliumotel
+
+// Version is the version of the xylium-otel connector. It is reported as the instrumentation
+// version of all tracers created by the connector and as the `otel.library.version` resource
+// attribute, allowing backends to distinguish instrumentation upgrades.
+const Version = "0.1.0"